@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configKeys says how the settings of a config file map onto the flags of
+// a command
+type configKeys struct {
+	// names gives the single letter flags longer names.  Any other flag is
+	// set by its own name.
+	names map[string]string
+	// lists holds the flags that take a comma separated list of values
+	lists map[string]bool
+	// lenient skips settings that do not apply to the command instead of
+	// failing, so that a profile written for solving can be used for
+	// validating too
+	lenient bool
+}
+
+// solveConfig maps the settings of a config file onto the solving flags
+var solveConfig = configKeys{
+	names: map[string]string{
+		"files":      "f",
+		"debug":      "d",
+		"strategies": "s",
+		"time":       "t",
+		"workers":    "j",
+		"output":     "o",
+	},
+	lists: map[string]bool{"s": true},
+}
+
+// validateConfig maps the settings of a solving profile onto the validate
+// flags.  The problem file is taken from files when it names only one, and
+// strategies are skipped, since -s names the solution file when validating.
+var validateConfig = configKeys{
+	names:   map[string]string{"files": "f"},
+	lenient: true,
+}
+
+// applyConfig sets the flags from a YAML or JSON config file, such as
+//
+//	strategies: [nearest, alns]
+//	time: 30s
+//	driver-cost: 650
+//	alns-iter: 5000
+//
+// Flags already given on the command line keep their values.  Keys that are not
+// flags of the flag set, and lists given to a flag that takes a single value, are
+// an error unless the keys are lenient, in which case they are skipped.
+func applyConfig(flags *flag.FlagSet, path string, keys configKeys) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// JSON is also YAML, so one decoder reads both
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	// Files named after the flags replace any in the config file
+	if flags.NArg() > 0 {
+		given["f"] = true
+	}
+
+	settings := make([]string, 0, len(config))
+	for key := range config {
+		settings = append(settings, key)
+	}
+	sort.Strings(settings)
+	for _, key := range settings {
+		name := key
+		if short, ok := keys.names[key]; ok {
+			name = short
+		}
+		if name == "config" || flags.Lookup(name) == nil {
+			if keys.lenient {
+				continue
+			}
+			return fmt.Errorf("%s: unknown setting '%s'", path, key)
+		}
+		if given[name] {
+			continue
+		}
+
+		values, err := configValues(config[key])
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		// Repeatable flags take each value in turn, list flags a comma separated
+		// list and the others a single value
+		if _, ok := flags.Lookup(name).Value.(*fileList); !ok {
+			if len(values) > 1 && !keys.lists[name] {
+				if keys.lenient {
+					continue
+				}
+				return fmt.Errorf("%s: %s: expected a single value", path, key)
+			}
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, v := range values {
+			if err := flags.Set(name, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configValues turns the value of a setting into the text of flag values
+func configValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				return nil, fmt.Errorf("expected a value or a list of values")
+			}
+			values[i] = fmt.Sprint(item)
+		}
+		return values, nil
+	case map[string]interface{}:
+		return nil, fmt.Errorf("expected a value or a list of values")
+	case nil:
+		return []string{""}, nil
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// dumpConfig writes the value of every flag as a YAML config file, which
+// reproduces the run when given back with -config
+func dumpConfig(w io.Writer, flags *flag.FlagSet) error {
+	longNames := map[string]string{}
+	for long, short := range solveConfig.names {
+		longNames[short] = long
+	}
+
+	config := map[string]interface{}{}
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		key := f.Name
+		if long, ok := longNames[key]; ok {
+			key = long
+		}
+		switch v := f.Value.(flag.Getter).Get().(type) {
+		case time.Duration:
+			config[key] = v.String()
+		default:
+			config[key] = v
+		}
+	})
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
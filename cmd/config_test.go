@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newConfigFlags registers a selection of the solving flags, short and long,
+// of every kind, on a flag set of their own
+func newConfigFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	var files fileList
+	flags.Var(&files, "f", "")
+	flags.String("s", "nearest", "")
+	flags.Duration("t", 0, "")
+	flags.Int("j", 1, "")
+	flags.Bool("d", false, "")
+	flags.String("format", "auto", "")
+	flags.String("config", "", "")
+	addCostFlags(flags)
+	return flags
+}
+
+// writeConfig writes the text of a config file to a file of its own and returns its path
+func writeConfig(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseWithConfig parses the solving arguments and then applies the config file
+func parseWithConfig(t *testing.T, text string, args ...string) (*flag.FlagSet, error) {
+	t.Helper()
+	flags := newConfigFlags()
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags, applyConfig(flags, writeConfig(t, text), solveConfig)
+}
+
+// checkFlag checks the value of a flag as text
+func checkFlag(t *testing.T, flags *flag.FlagSet, name, want string) {
+	t.Helper()
+	if got := flags.Lookup(name).Value.String(); got != want {
+		t.Fatalf("wrong value for -%s.  wanted='%s', got='%s'", name, want, got)
+	}
+}
+
+func TestConfigFlagsOverride(t *testing.T) {
+	config := "strategies: [nearest, alns]\ntime: 30s\ndriver-cost: 650\nworkers: 4\n"
+	flags, err := parseWithConfig(t, config, "-s", "savings", "-driver-cost", "700")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlag(t, flags, "s", "savings")
+	checkFlag(t, flags, "driver-cost", "700")
+	checkFlag(t, flags, "t", "30s")
+	checkFlag(t, flags, "j", "4")
+
+	// Lists become comma separated values of flags that are not repeatable
+	flags, err = parseWithConfig(t, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlag(t, flags, "s", "nearest,alns")
+}
+
+func TestConfigFiles(t *testing.T) {
+	config := "files: [a.txt, b.txt]\n"
+	flags, err := parseWithConfig(t, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlag(t, flags, "f", "a.txt,b.txt")
+
+	// Files named after the flags replace those of the config file
+	flags, err = parseWithConfig(t, config, "-d", "c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFlag(t, flags, "f", "")
+	if flags.NArg() != 1 || flags.Arg(0) != "c.txt" {
+		t.Fatalf("wrong files, got %v", flags.Args())
+	}
+}
+
+func TestConfigUnknownKeys(t *testing.T) {
+	if _, err := parseWithConfig(t, "colour: blue\ndriver-cost: 650\n"); err == nil {
+		t.Fatal("expected an error for an unknown setting")
+	}
+
+	// The config file cannot name another config file
+	if _, err := parseWithConfig(t, "config: other.yaml\n"); err == nil {
+		t.Fatal("expected an error for a config setting")
+	}
+
+	// Nor give a list to a flag that takes a single value
+	if _, err := parseWithConfig(t, "driver-cost: [650, 700]\n"); err == nil {
+		t.Fatal("expected an error for a list of driver costs")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	profile := "files: [north.csv]\nstrategies: [nearest, alns]\ntime: 30s\ndriver-cost: 650\noutput: json\ncolour: blue\n"
+	for _, tt := range []struct {
+		name     string
+		profile  string
+		args     []string
+		problem  string
+		solution string
+	}{
+		// Settings that only apply to solving are skipped, and -s is not taken
+		// from the strategies
+		{"solve profile", profile, nil, "north.csv", ""},
+		{"solution given", profile, []string{"-s", "north.txt"}, "north.csv", "north.txt"},
+		{"problem given", profile, []string{"-f", "south.csv"}, "south.csv", ""},
+		// Several files leave the problem to be named on the command line
+		{"several files", "files: [a.csv, b.csv]\n", nil, "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+			v := addValidateFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyConfig(flags, writeConfig(t, tt.profile), validateConfig); err != nil {
+				t.Fatal(err)
+			}
+			if v.problemPath != tt.problem || v.solutionPath != tt.solution {
+				t.Fatalf("wrong files.  wanted='%s' and '%s', got='%s' and '%s'", tt.problem, tt.solution, v.problemPath, v.solutionPath)
+			}
+		})
+	}
+
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	v := addValidateFlags(flags)
+	if err := applyConfig(flags, writeConfig(t, profile), validateConfig); err != nil {
+		t.Fatal(err)
+	}
+	if v.costs.costs.PerDriver != 650 {
+		t.Fatalf("wrong driver cost.  wanted=650, got=%v", v.costs.costs.PerDriver)
+	}
+}
+
+func TestConfigNestedValues(t *testing.T) {
+	for _, config := range []string{
+		"time:\n  minutes: 30\n",
+		"strategies: [{name: nearest}]\n",
+		`{"strategies": [{"name": "nearest"}]}`,
+	} {
+		if _, err := parseWithConfig(t, config); err == nil {
+			t.Fatalf("expected an error for '%s'", config)
+		}
+	}
+
+	// Values of the wrong kind are errors too
+	if _, err := parseWithConfig(t, "workers: many\n"); err == nil {
+		t.Fatal("expected an error for a worker count that is not a number")
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	flags := newConfigFlags()
+	args := []string{"-f", "a.txt", "-f", "b.txt", "-s", "savings,alns", "-t", "1m30s", "-j", "8", "-d",
+		"-driver-cost", "650.5", "-max-shift", "10h30m", "-loading", "20m", "-format", "csv"}
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := dumpConfig(&buf, flags); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reading the config back into flags that were not given reproduces them all
+	restored, err := parseWithConfig(t, buf.String())
+	if err != nil {
+		t.Fatalf("%s in\n%s", err, buf.String())
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		checkFlag(t, restored, f.Name, f.Value.String())
+	})
+	if got := restored.Lookup("t").Value.(flag.Getter).Get(); got != 90*time.Second {
+		t.Fatalf("wrong time budget, got %v", got)
+	}
+}
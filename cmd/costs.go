@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"sched/internal/models"
+)
+
+// costFlags holds the flags that set how solutions are priced, which shifts
+// are allowed and how long loading and unloading take, shared by solving and
+// validating
+type costFlags struct {
+	costs     models.CostModel
+	maxShift  time.Duration
+	loading   time.Duration
+	unloading time.Duration
+}
+
+// addCostFlags registers the cost, shift limit and service time flags on the
+// flag set, with the defaults of the models package
+func addCostFlags(flags *flag.FlagSet) *costFlags {
+	c := &costFlags{
+		costs:    models.DefaultCostModel(),
+		maxShift: time.Duration(models.DefaultConstraints().MaxShiftMinutes) * time.Minute,
+	}
+	flags.Float64Var(&c.costs.PerDriver, "driver-cost", c.costs.PerDriver, "The fixed cost of each driver used")
+	flags.Float64Var(&c.costs.PerMinute, "minute-cost", c.costs.PerMinute, "The cost of each minute of a shift (one unit of distance takes one minute to drive)")
+	flags.DurationVar(&c.maxShift, "max-shift", c.maxShift, "The longest shift a driver may work, including the drive home (e.g. 10h30m)")
+	flags.DurationVar(&c.loading, "loading", c.loading, "The time spent loading at each pickup, for loads that do not give their own (e.g. 30m)")
+	flags.DurationVar(&c.unloading, "unloading", c.unloading, "The time spent unloading at each dropoff, for loads that do not give their own (e.g. 30m)")
+	return c
+}
+
+// constraints returns the shift limits set by the flags
+func (c *costFlags) constraints() models.Constraints {
+	return models.Constraints{MaxShiftMinutes: c.maxShift.Minutes()}
+}
+
+// service returns the default service times set by the flags
+func (c *costFlags) service() models.Service {
+	return models.Service{Loading: c.loading.Minutes(), Unloading: c.unloading.Minutes()}
+}
+
+// validate checks that the flags make sense
+func (c *costFlags) validate() error {
+	if err := c.costs.Validate(); err != nil {
+		return err
+	}
+	if err := c.service().Validate(); err != nil {
+		return err
+	}
+	return c.constraints().Validate()
+}
+
+// apply sets the costs, shift limits and service times on the load set
+func (c *costFlags) apply(loadset *models.LoadSet) {
+	loadset.SetCostModel(c.costs)
+	loadset.SetConstraints(c.constraints())
+	loadset.SetDefaultService(c.service())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"sched/internal/models"
+)
+
+// parseCostFlags registers the cost flags on a flag set of their own and parses the arguments
+func parseCostFlags(t *testing.T, args ...string) *costFlags {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	costs := addCostFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return costs
+}
+
+func TestCostFlagsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{}, true},
+		{[]string{"-driver-cost", "0"}, true},
+		{[]string{"-minute-cost", "0"}, true},
+		{[]string{"-driver-cost", "0", "-minute-cost", "0"}, true},
+		{[]string{"-driver-cost", "-1"}, false},
+		{[]string{"-minute-cost", "-0.5"}, false},
+		{[]string{"-max-shift", "10h30m"}, true},
+		{[]string{"-max-shift", "0"}, false},
+		{[]string{"-max-shift", "-1h"}, false},
+		{[]string{"-loading", "0", "-unloading", "0"}, true},
+		{[]string{"-loading", "-5m"}, false},
+		{[]string{"-unloading", "-5m"}, false},
+	} {
+		err := parseCostFlags(t, tt.args...).validate()
+		if tt.valid && err != nil {
+			t.Fatalf("%v: %s", tt.args, err)
+		}
+		if !tt.valid && err == nil {
+			t.Fatalf("%v: expected an error", tt.args)
+		}
+	}
+}
+
+func TestCostFlagsApply(t *testing.T) {
+	for _, tt := range []struct {
+		args        []string
+		costs       models.CostModel
+		constraints models.Constraints
+		service     models.Service
+	}{
+		{
+			[]string{},
+			models.DefaultCostModel(),
+			models.DefaultConstraints(),
+			models.Service{},
+		},
+		{
+			[]string{"-driver-cost", "650", "-minute-cost", "0", "-max-shift", "10h30m", "-loading", "30m", "-unloading", "90s"},
+			models.CostModel{PerDriver: 650, PerMinute: 0},
+			models.Constraints{MaxShiftMinutes: 630},
+			models.Service{Loading: 30, Unloading: 1.5},
+		},
+	} {
+		loadset := models.NewLoadSet()
+		parseCostFlags(t, tt.args...).apply(loadset)
+
+		if loadset.CostModel() != tt.costs {
+			t.Fatalf("%v: wrong cost model.  wanted=%+v, got=%+v", tt.args, tt.costs, loadset.CostModel())
+		}
+		if loadset.Constraints() != tt.constraints {
+			t.Fatalf("%v: wrong constraints.  wanted=%+v, got=%+v", tt.args, tt.constraints, loadset.Constraints())
+		}
+		if loadset.DefaultService() != tt.service {
+			t.Fatalf("%v: wrong service times.  wanted=%+v, got=%+v", tt.args, tt.service, loadset.DefaultService())
+		}
+	}
+}
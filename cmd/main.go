@@ -3,39 +3,229 @@
 //
 // Usage:
 //
-//	./schedule -f /path/to/problem_file
+//	./schedule [-f /path/to/problem_file ...] [-format auto|text|csv|json] [-config profile.yaml] [-d]
+//	           [-s strategy[,strategy...]] [-t duration] [-j workers] [-seed seed] [-round]
+//	           [-driver-cost cost] [-minute-cost cost] [-max-shift duration] [-loading duration] [-unloading duration]
+//	           [-neighbors n] [-starts n] [-random-starts n]
+//	           [-anneal-temp temperature] [-anneal-cooling rate] [-anneal-iter n] [-anneal-time duration]
+//	           [-alns-iter n] [-alns-time duration]
+//	           [-o text|json] [-itinerary] [-shift-start hh:mm] [-svg map.svg] [-html report.html] [problem_file ...]
+//
+// Each flag is described by -h, and at more length in README.md.
+//
+// Problem files are given with -f, which may be repeated, or after the flags.  The
+// problem is read from standard input when the file is - or no file is given at all.
+// When several problems are solved, each solution is preceded by a line "# file".
+// With -o json, each solution is instead printed as a JSON document of its own.
+//
+// A solution, such as one printed by an earlier run or edited by hand, is checked and scored with
+//
+//	./schedule validate -f /path/to/problem_file -s /path/to/solution_file [-format auto|text|csv|json] [-config profile.yaml]
+//	           [-round] [-driver-cost cost] [-minute-cost cost] [-max-shift duration] [-loading duration] [-unloading duration]
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
+	"time"
 
+	"sched/internal/models"
 	"sched/internal/reader"
 	"sched/internal/solver"
 )
 
 func main() {
-	// Get the filepath and quit if there is none
-	var filepath string
-	flag.StringVar(&filepath, "f", "", "The full path of the file containing the problem to be solved")
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validate(os.Args[2:]))
+	}
+
+	var configPath string
+	flag.StringVar(&configPath, "config", "", "A YAML or JSON file of settings, named like the flags (with files, debug, strategies, "+
+		"time, workers and output for the single letter ones); flags given on the command line take precedence")
+
+	// Get the problem files, reading from standard input if there are none
+	var filepaths fileList
+	flag.Var(&filepaths, "f", "The full path of a file containing a problem to be solved (- for standard input); may be repeated")
+
+	var formatName string
+	flag.StringVar(&formatName, "format", "auto", "The format of the problem file (auto, text, csv or json)")
+
+	var output string
+	flag.StringVar(&output, "o", "text", "The form the solution is printed in: text, with a line for each driver's loads, "+
+		"or json, with the cost and minutes of each route")
+
+	var itinerary bool
+	flag.BoolVar(&itinerary, "itinerary", false, "Print the legs of each driver's shift, with the time each one starts and ends")
+
+	var shiftStart string
+	flag.StringVar(&shiftStart, "shift-start", "00:00", "The time of day (hh:mm) at which shifts start, for the itinerary")
+
+	var svgPath string
+	flag.StringVar(&svgPath, "svg", "", "Draw a map of the routes in an SVG file at the given path "+
+		"(with the name of each problem added when several are solved)")
+
+	var htmlPath string
+	flag.StringVar(&htmlPath, "html", "", "Write a report of the cost and of each driver's shift, as a Gantt chart, in an HTML file at the given path "+
+		"(with the name of each problem added when several are solved)")
 
 	var debug bool
 	flag.BoolVar(&debug, "d", false, "Turns on debug printing")
 
+	var strategyNames string
+	flag.StringVar(&strategyNames, "s", "nearest", "Comma separated strategies to run, keeping the cheapest solution ("+strings.Join(solver.Names(), ", ")+")")
+
+	var budget time.Duration
+	flag.DurationVar(&budget, "t", 0, "The wall-clock time allowed for solving (e.g. 30s); the best solution found in that time is printed. "+
+		"Without it, each strategy makes a fixed number of attempts")
+
+	var round bool
+	flag.BoolVar(&round, "round", false, "Measure distances between coordinates rounded to the nearest integer instead of the exact ones")
+
+	costs := addCostFlags(flag.CommandLine)
+
+	var seed int64
+	flag.Int64Var(&seed, "seed", 0, "The seed for all random choices, so that a run can be repeated exactly (default: taken from the clock)")
+
+	var workers int
+	flag.IntVar(&workers, "j", runtime.GOMAXPROCS(0), "The number of solutions constructed and improved at the same time")
+
+	// The strategies are built with these settings, each taking those that apply to it
+	params := solver.DefaultParams()
+
+	// The nearest neighbor walks that start the nearest, anneal and alns strategies
+	walk := &params.Walk
+	flag.IntVar(&walk.Neighbors, "neighbors", walk.Neighbors, "The number of nearest pickups each step of a nearest neighbor walk chooses between")
+	flag.IntVar(&walk.Deterministic, "starts", walk.Deterministic, "The number of nearest neighbor walks that always take the same choice of neighbor")
+	flag.IntVar(&walk.Randomized, "random-starts", walk.Randomized, "The number of nearest neighbor walks that choose neighbors at random")
+
+	// The anneal strategy can be tuned to run longer on larger problems
+	schedule := &params.Anneal
+	flag.Float64Var(&schedule.InitialTemperature, "anneal-temp", schedule.InitialTemperature, "The starting temperature of the anneal strategy, in units of cost")
+	flag.Float64Var(&schedule.CoolingRate, "anneal-cooling", schedule.CoolingRate, "The factor applied to the anneal temperature after each iteration")
+	flag.IntVar(&schedule.Iterations, "anneal-iter", schedule.Iterations, "The number of iterations of the anneal strategy (0 for no limit)")
+	flag.DurationVar(&schedule.Budget, "anneal-time", schedule.Budget, "The wall-clock time allowed for the anneal strategy (0 for no limit)")
+
+	// As can the alns strategy
+	search := &params.ALNS
+	flag.IntVar(&search.Iterations, "alns-iter", search.Iterations, "The number of iterations of the alns strategy (0 for no limit)")
+	flag.DurationVar(&search.Budget, "alns-time", search.Budget, "The wall-clock time allowed for the alns strategy (0 for no limit)")
+
 	flag.Parse()
 
-	if filepath == "" {
-		_, _ = fmt.Println("Cannot proceed without problem file")
+	if configPath != "" {
+		if err := applyConfig(flag.CommandLine, configPath, solveConfig); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	// Only pick a seed when none was given, so that a seed of 0 can be repeated too
+	seeded := false
+	flag.Visit(func(f *flag.Flag) {
+		seeded = seeded || f.Name == "seed"
+	})
+	if !seeded {
+		seed = time.Now().UnixNano()
+	}
+
+	if debug {
+		_, _ = fmt.Println("Configuration:")
+		if err := dumpConfig(os.Stdout, flag.CommandLine); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		_, _ = fmt.Println()
+	}
+
+	filepaths = append(filepaths, flag.Args()...)
+	if len(filepaths) == 0 {
+		filepaths = fileList{"-"}
+	}
+	several := len(filepaths) > 1
+
+	format, err := reader.ParseFormat(formatName)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := costs.validate(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	// Read the file and create a data structure holding the set of loads in the problem.
-	// If there was a problem reading the file or creating the load set, exit.
-	loadset := reader.CreateLoadSet(filepath)
-	if loadset == nil {
+	if output != "text" && output != "json" {
+		_, _ = fmt.Fprintf(os.Stderr, "Unknown output '%s', expected text or json\n", output)
+		os.Exit(1)
+	}
+	start, err := parseClock(shiftStart)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	p := printer{
+		json:       output == "json",
+		itinerary:  itinerary,
+		shiftStart: start,
+		svg:        svgPath,
+		html:       htmlPath,
+		several:    several,
+	}
+
+	strategies, err := solver.LookupAll(strings.Split(strategyNames, ","), params)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, filepath := range filepaths {
+		if several && output == "text" {
+			_, _ = fmt.Printf("# %s\n", filepath)
+		}
+		err := solveFile(filepath, format, costs, budget, round, debug, solver.Options{
+			Strategies: strategies,
+			Workers:    workers,
+			Seed:       seed,
+			Debug:      debug,
+		}, p)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", filepath, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// solveFile solves the problem in a single file and prints the solution with the
+// given printer.  The time budget, if there is one, covers everything including
+// reading the file.
+func solveFile(filepath string, format reader.Format, costs *costFlags, budget time.Duration, round bool, debug bool, opts solver.Options, p printer) error {
+	ctx := context.Background()
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	// Read the file and create a data structure holding the set of loads in the problem
+	loadset, err := readProblem(filepath, format, costs)
+	if err != nil {
+		return err
+	}
+
+	if round {
+		loadset.RoundCoordinates()
+	}
+	if err := checkServable(loadset); err != nil {
+		return err
+	}
 
 	if debug {
 		println()
@@ -43,8 +233,56 @@ func main() {
 	}
 
 	// Find a reasonably efficient solution
-	solution := solver.SolveLoadSet(loadset, debug)
-	for _, s := range solution {
-		_, _ = fmt.Printf("[%s]\n", s)
+	return p.print(filepath, solver.Solve(ctx, loadset, opts))
+}
+
+// readProblem reads the problem in a file, or in standard input if the file is -,
+// reporting every line that cannot be read, and prices it with the given costs
+func readProblem(filepath string, format reader.Format, costs *costFlags) (*models.LoadSet, error) {
+	var loadset *models.LoadSet
+	var err error
+	if filepath == "-" {
+		loadset, err = reader.ReadLoadSet(os.Stdin, "", format, true)
+	} else {
+		loadset, err = reader.CreateLoadSetFormat(filepath, format, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	costs.apply(loadset)
+	return loadset, nil
+}
+
+// checkServable reports the loads of the problem, if any, that no driver can
+// complete within the shift limit, given the windows and service times of the load
+func checkServable(loadset *models.LoadSet) error {
+	unservable := loadset.Unservable()
+	if len(unservable) == 0 {
+		return nil
+	}
+	ids := make([]string, len(unservable))
+	for i, load := range unservable {
+		ids[i] = load.ID
 	}
+	noun := "load"
+	if len(ids) > 1 {
+		noun = "loads"
+	}
+	return fmt.Errorf("no driver can complete %s %s within a shift of %.0f minutes, given its windows and service times", noun, strings.Join(ids, ", "), loadset.ShiftLimit())
+}
+
+// fileList collects the values of a flag that may be given more than once
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func (f *fileList) Get() interface{} {
+	return []string(*f)
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sched/internal/models"
+	"sched/internal/render"
+	"sched/internal/solver"
+)
+
+// printer prints solutions in the form chosen on the command line, and
+// writes any other files that were asked for
+type printer struct {
+	// json prints a JSON document instead of a line for each route
+	json bool
+	// itinerary adds the legs of each shift
+	itinerary bool
+	// shiftStart is the minute of the day at which every shift starts
+	shiftStart float64
+	// svg is the path of the file to draw a map of the routes in, if any
+	svg string
+	// html is the path of the file to write a report of the shifts to, if any
+	html string
+	// several is set when several problems are solved, so that
+	// each of them writes files of its own
+	several bool
+}
+
+// jsonSolution is the solution to a problem as printed with -o json
+type jsonSolution struct {
+	Problem  string `json:"problem"`
+	Strategy string `json:"strategy"`
+	// The seed is written as a string, as seeds taken from the
+	// clock are too large for many JSON readers to hold exactly
+	Seed    int64        `json:"seed,string"`
+	Cost    jsonCost     `json:"cost"`
+	Drivers []jsonDriver `json:"drivers"`
+}
+
+// jsonCost breaks down the cost of a solution
+type jsonCost struct {
+	Total        float64 `json:"total"`
+	DriverCost   float64 `json:"driverCost"`
+	DistanceCost float64 `json:"distanceCost"`
+	Drivers      int     `json:"drivers"`
+	TotalMinutes float64 `json:"totalMinutes"`
+}
+
+// jsonDriver is the route of a single driver
+type jsonDriver struct {
+	Loads           []string  `json:"loads"`
+	Minutes         float64   `json:"minutes"`
+	LoadedMinutes   float64   `json:"loadedMinutes"`
+	DeadheadMinutes float64   `json:"deadheadMinutes"`
+	WaitingMinutes  float64   `json:"waitingMinutes"`
+	ServiceMinutes  float64   `json:"serviceMinutes"`
+	SlackMinutes    float64   `json:"slackMinutes"`
+	Itinerary       []jsonLeg `json:"itinerary,omitempty"`
+}
+
+// jsonLeg is a single drive or stop of a shift, with its start and end given both
+// in minutes since midnight and as a time of day
+type jsonLeg struct {
+	Type      models.LegType `json:"type"`
+	Load      string         `json:"load,omitempty"`
+	From      jsonPoint      `json:"from"`
+	To        jsonPoint      `json:"to"`
+	Start     float64        `json:"start"`
+	End       float64        `json:"end"`
+	StartTime string         `json:"startTime"`
+	EndTime   string         `json:"endTime"`
+}
+
+// jsonPoint is where a leg starts or ends
+type jsonPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// print prints the solution to the named problem
+func (p printer) print(problem string, result *solver.Result) error {
+	if p.json {
+		if err := p.printJSON(os.Stdout, problem, result); err != nil {
+			return err
+		}
+	} else {
+		p.printText(os.Stdout, result)
+	}
+
+	if result.Stable == nil {
+		return nil
+	}
+	if p.svg != "" {
+		if err := p.writeFile(p.svg, problem, func(w io.Writer) error {
+			return render.SVG(w, result.Stable)
+		}); err != nil {
+			return err
+		}
+	}
+	if p.html != "" {
+		if err := p.writeFile(p.html, problem, func(w io.Writer) error {
+			return render.Report(w, "Shifts for "+problem, result.Stable)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile creates the file at path and fills it using write.  When several
+// problems are solved, the name of the problem is added to the name of the file.
+func (p printer) writeFile(path string, problem string, write func(io.Writer) error) error {
+	if p.several {
+		name := filepath.Base(problem)
+		if problem == "-" {
+			name = "stdin"
+		}
+		ext := filepath.Ext(path)
+		path = strings.TrimSuffix(path, ext) + "-" + name + ext
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printText writes the loads of each driver's route on a line of its own,
+// followed by the legs of the shift if the itinerary was asked for
+func (p printer) printText(w io.Writer, result *solver.Result) {
+	if result.Stable == nil {
+		return
+	}
+	var itineraries [][]models.Leg
+	if p.itinerary {
+		itineraries = result.Stable.Itineraries(p.shiftStart)
+	}
+	for i, s := range result.Stable.Solution() {
+		_, _ = fmt.Fprintf(w, "[%s]\n", s)
+		if p.itinerary {
+			for _, l := range itineraries[i] {
+				line := fmt.Sprintf("    %s-%s  %-9s  %s", formatClock(l.Start), formatClock(l.End), l.Type, l.Load)
+				_, _ = fmt.Fprintln(w, strings.TrimRight(line, " "))
+			}
+		}
+	}
+}
+
+// printJSON writes the solution, along with how it was found and what it
+// costs, as a JSON document
+func (p printer) printJSON(w io.Writer, problem string, result *solver.Result) error {
+	solution := jsonSolution{
+		Problem:  problem,
+		Strategy: result.Strategy,
+		Seed:     result.Seed,
+		Drivers:  []jsonDriver{},
+	}
+	if result.Stable != nil {
+		var itineraries [][]models.Leg
+		if p.itinerary {
+			itineraries = result.Stable.Itineraries(p.shiftStart)
+		}
+		for i, summary := range result.Stable.Summaries() {
+			driver := jsonDriver{
+				Loads:           summary.Loads,
+				Minutes:         summary.Minutes,
+				LoadedMinutes:   summary.LoadedMinutes,
+				DeadheadMinutes: summary.DeadheadMinutes,
+				WaitingMinutes:  summary.WaitingMinutes,
+				ServiceMinutes:  summary.ServiceMinutes,
+				SlackMinutes:    summary.SlackMinutes,
+			}
+			if p.itinerary {
+				for _, l := range itineraries[i] {
+					driver.Itinerary = append(driver.Itinerary, jsonLeg{
+						Type:      l.Type,
+						Load:      l.Load,
+						From:      jsonPoint{l.From.ExactX, l.From.ExactY},
+						To:        jsonPoint{l.To.ExactX, l.To.ExactY},
+						Start:     l.Start,
+						End:       l.End,
+						StartTime: formatClock(l.Start),
+						EndTime:   formatClock(l.End),
+					})
+				}
+			}
+			solution.Drivers = append(solution.Drivers, driver)
+			solution.Cost.TotalMinutes += summary.Minutes
+		}
+		solution.Cost.Drivers = len(solution.Drivers)
+		solution.Cost.DriverCost, solution.Cost.DistanceCost = result.Stable.CostBreakdown()
+		solution.Cost.Total = result.Stable.CalculateCost()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(solution)
+}
+
+// parseClock turns a time of day of the form hh:mm into minutes since midnight
+func parseClock(clock string) (float64, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) == 2 {
+		h, herr := strconv.Atoi(parts[0])
+		m, merr := strconv.Atoi(parts[1])
+		if herr == nil && merr == nil && h >= 0 && h < 24 && m >= 0 && m < 60 {
+			return float64(60*h + m), nil
+		}
+	}
+	return 0, fmt.Errorf("'%s' is not a time of day of the form hh:mm", clock)
+}
+
+// formatClock writes minutes since midnight as a time of day, hh:mm, rounded
+// to the nearest minute.  Times past midnight carry on counting the hours.
+func formatClock(minutes float64) string {
+	m := int(math.Round(minutes))
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
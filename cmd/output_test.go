@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	"sched/internal/models"
+	"sched/internal/solver"
+)
+
+// solveTwoLoads solves a problem of two loads up the y axis, where the first
+// cannot be picked up before minute 15 and every load takes 2 minutes to load
+// and 3 to unload.  The only sensible solution has a single driver, who
+// drives 10 minutes to A, waits 5, loads 2, carries 10 and unloads 3, drives
+// 10 minutes to B, loads 2, carries 10 and unloads 3, and drives 40 minutes
+// home: 95 minutes in all.
+func solveTwoLoads(t *testing.T) *solver.Result {
+	loadset := models.NewLoadSet()
+	first := models.NewLoad("A", models.NewLocation(models.Pickup, 0, 10), models.NewLocation(models.Dropoff, 0, 20), false)
+	first.PickupWindow = models.Window{Earliest: 15}
+	loadset.AddLoad(first)
+	loadset.AddLoad(models.NewLoad("B", models.NewLocation(models.Pickup, 0, 30), models.NewLocation(models.Dropoff, 0, 40), false))
+	loadset.FormDistanceMatrix()
+	loadset.SetDefaultService(models.Service{Loading: 2, Unloading: 3})
+
+	result := solver.Solve(context.Background(), loadset, solver.Options{
+		Strategies: []solver.Strategy{&solver.NearestNeighbor{Walk: solver.DefaultWalkParams()}},
+		Seed:       1234567890123456789,
+	})
+	if result.Stable == nil {
+		t.Fatal("no solution found")
+	}
+	return result
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (printer{json: true}).printJSON(&buf, "two.txt", solveTwoLoads(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	checkKeys(t, "solution", doc, "cost", "drivers", "problem", "seed", "strategy")
+	if doc["problem"] != "two.txt" || doc["strategy"] != "nearest" {
+		t.Fatalf("wrong problem or strategy, got '%v' and '%v'", doc["problem"], doc["strategy"])
+	}
+	// Seeds are too large to be numbers in many JSON readers
+	if doc["seed"] != "1234567890123456789" {
+		t.Fatalf("the seed should be written as a string, got %#v", doc["seed"])
+	}
+
+	cost := doc["cost"].(map[string]interface{})
+	checkKeys(t, "cost", cost, "distanceCost", "driverCost", "drivers", "total", "totalMinutes")
+	checkNumbers(t, "cost", cost, map[string]float64{
+		"total":        595,
+		"driverCost":   500,
+		"distanceCost": 95,
+		"drivers":      1,
+		"totalMinutes": 95,
+	})
+
+	drivers := doc["drivers"].([]interface{})
+	if len(drivers) != 1 {
+		t.Fatalf("wrong number of drivers.  wanted=1, got=%d", len(drivers))
+	}
+	driver := drivers[0].(map[string]interface{})
+	checkKeys(t, "driver", driver, "deadheadMinutes", "loadedMinutes", "loads", "minutes", "serviceMinutes", "slackMinutes", "waitingMinutes")
+	if loads := driver["loads"].([]interface{}); len(loads) != 2 || loads[0] != "A" || loads[1] != "B" {
+		t.Fatalf("wrong loads, got %v", loads)
+	}
+	checkNumbers(t, "driver", driver, map[string]float64{
+		"minutes":         95,
+		"loadedMinutes":   20,
+		"deadheadMinutes": 60,
+		"waitingMinutes":  5,
+		"serviceMinutes":  10,
+		"slackMinutes":    625,
+	})
+}
+
+func TestPrintJSONWithoutSolution(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (printer{json: true}).printJSON(&buf, "none.txt", &solver.Result{Strategy: "nearest"}); err != nil {
+		t.Fatal(err)
+	}
+	// An empty list rather than null, so that readers can always loop over it
+	if !strings.Contains(buf.String(), `"drivers": []`) {
+		t.Fatalf("expected an empty list of drivers, got %s", buf.String())
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for clock, want := range map[string]float64{
+		"00:00": 0,
+		"08:30": 510,
+		"8:05":  485,
+		"23:59": 1439,
+	} {
+		got, err := parseClock(clock)
+		if err != nil {
+			t.Fatalf("'%s': %s", clock, err)
+		}
+		if got != want {
+			t.Fatalf("'%s': wanted=%v, got=%v", clock, want, got)
+		}
+	}
+
+	for _, clock := range []string{"", "8", "08:", ":30", "24:00", "08:60", "-1:30", "08:-5", "8h30", "08:30:00", "aa:bb"} {
+		if _, err := parseClock(clock); err == nil {
+			t.Fatalf("should not have parsed '%s'", clock)
+		}
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	for minutes, want := range map[float64]string{
+		0:      "00:00",
+		510:    "08:30",
+		89.4:   "01:29",
+		89.5:   "01:30",
+		1439.6: "24:00",
+		1565:   "26:05",
+	} {
+		if got := formatClock(minutes); got != want {
+			t.Fatalf("%v: wanted='%s', got='%s'", minutes, want, got)
+		}
+	}
+}
+
+func TestPrintItinerary(t *testing.T) {
+	var buf bytes.Buffer
+	(printer{itinerary: true, shiftStart: 480}).printText(&buf, solveTwoLoads(t))
+
+	want := strings.Join([]string{
+		"[A,B]",
+		"    08:00-08:10  to-pickup  A",
+		"    08:10-08:15  waiting    A",
+		"    08:15-08:17  loading    A",
+		"    08:17-08:27  carrying   A",
+		"    08:27-08:30  unloading  A",
+		"    08:30-08:40  to-pickup  B",
+		"    08:40-08:42  loading    B",
+		"    08:42-08:52  carrying   B",
+		"    08:52-08:55  unloading  B",
+		"    08:55-09:35  to-home",
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Fatalf("wrong itinerary.  wanted=\n%s\ngot=\n%s", want, buf.String())
+	}
+
+	// Without the itinerary, only the loads are printed
+	buf.Reset()
+	(printer{shiftStart: 480}).printText(&buf, solveTwoLoads(t))
+	if buf.String() != "[A,B]\n" {
+		t.Fatalf("wrong solution, got '%s'", buf.String())
+	}
+}
+
+func TestPrintJSONItinerary(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (printer{json: true, itinerary: true, shiftStart: 480}).printJSON(&buf, "two.txt", solveTwoLoads(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Drivers []struct {
+			Itinerary []map[string]interface{} `json:"itinerary"`
+		} `json:"drivers"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	legs := doc.Drivers[0].Itinerary
+	if len(legs) != 10 {
+		t.Fatalf("wrong number of legs.  wanted=10, got=%d", len(legs))
+	}
+
+	first := legs[0]
+	checkKeys(t, "leg", first, "end", "endTime", "from", "load", "start", "startTime", "to", "type")
+	if first["type"] != "to-pickup" || first["load"] != "A" || first["startTime"] != "08:00" || first["endTime"] != "08:10" {
+		t.Fatalf("wrong first leg, got %v", first)
+	}
+	checkNumbers(t, "first leg", first, map[string]float64{"start": 480, "end": 490})
+	checkNumbers(t, "first leg from", first["from"].(map[string]interface{}), map[string]float64{"x": 0, "y": 0})
+	checkNumbers(t, "first leg to", first["to"].(map[string]interface{}), map[string]float64{"x": 0, "y": 10})
+
+	// The drive home has no load
+	last := legs[len(legs)-1]
+	checkKeys(t, "leg", last, "end", "endTime", "from", "start", "startTime", "to", "type")
+	if last["type"] != "to-home" || last["startTime"] != "08:55" || last["endTime"] != "09:35" {
+		t.Fatalf("wrong last leg, got %v", last)
+	}
+	checkNumbers(t, "last leg", last, map[string]float64{"start": 535, "end": 575})
+}
+
+// checkKeys checks that the JSON object has exactly the given keys
+func checkKeys(t *testing.T, name string, object map[string]interface{}, keys ...string) {
+	t.Helper()
+	got := make([]string, 0, len(object))
+	for key := range object {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(keys, ",") {
+		t.Fatalf("wrong keys for %s.  wanted=%v, got=%v", name, keys, got)
+	}
+}
+
+// checkNumbers checks the numbers held by the JSON object
+func checkNumbers(t *testing.T, name string, object map[string]interface{}, want map[string]float64) {
+	t.Helper()
+	for key, value := range want {
+		if got, ok := object[key].(float64); !ok || math.Abs(got-value) > 1e-9 {
+			t.Fatalf("wrong %s %s.  wanted=%v, got=%v", name, key, value, object[key])
+		}
+	}
+}
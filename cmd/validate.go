@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"sched/internal/reader"
+	"sched/internal/validator"
+)
+
+// validateFlags holds the flags of the validate command
+type validateFlags struct {
+	problemPath  string
+	formatName   string
+	solutionPath string
+	round        bool
+	costs        *costFlags
+	configPath   string
+}
+
+// addValidateFlags registers the flags of the validate command on the flag set
+func addValidateFlags(flags *flag.FlagSet) *validateFlags {
+	v := &validateFlags{}
+	flags.StringVar(&v.problemPath, "f", "", "The full path of the file containing the problem (- for standard input)")
+	flags.StringVar(&v.formatName, "format", "auto", "The format of the problem file (auto, text, csv or json)")
+	flags.StringVar(&v.solutionPath, "s", "", "The full path of the file containing the solution to be checked (- for standard input)")
+	flags.BoolVar(&v.round, "round", false, "Measure distances between coordinates rounded to the nearest integer instead of the exact ones")
+	v.costs = addCostFlags(flags)
+	flags.StringVar(&v.configPath, "config", "", "A YAML or JSON file of settings, as for solving; settings that do not apply to validating are skipped")
+	return v
+}
+
+// validate checks a solution file against a problem file and prints its cost
+// along with anything that is wrong with it.  It returns the exit status.
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	v := addValidateFlags(flags)
+
+	_ = flags.Parse(args)
+
+	if v.configPath != "" {
+		if err := applyConfig(flags, v.configPath, validateConfig); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if v.problemPath == "" || v.solutionPath == "" {
+		_, _ = fmt.Fprintln(os.Stderr, "Cannot validate without both a problem file and a solution file")
+		return 1
+	}
+
+	format, err := reader.ParseFormat(v.formatName)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := v.costs.validate(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if v.problemPath == "-" && v.solutionPath == "-" {
+		_, _ = fmt.Fprintln(os.Stderr, "Cannot read both the problem and the solution from standard input")
+		return 1
+	}
+
+	loadset, err := readProblem(v.problemPath, format, v.costs)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if v.round {
+		loadset.RoundCoordinates()
+	}
+
+	var routes [][]string
+	if v.solutionPath == "-" {
+		routes, err = reader.ReadSolutionFrom(os.Stdin)
+	} else {
+		routes, err = reader.ReadSolution(v.solutionPath)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	report := validator.Validate(loadset, routes)
+
+	_, _ = fmt.Printf("Drivers: %d\n", len(report.Routes))
+	_, _ = fmt.Printf("Total minutes: %.2f\n", report.TotalMinutes)
+	_, _ = fmt.Printf("Cost: %.2f\n", report.Cost)
+
+	for i, route := range report.Routes {
+		for _, problem := range route.Problems {
+			_, _ = fmt.Printf("Driver %d: %s\n", i+1, problem)
+		}
+	}
+	for _, n := range report.Missing {
+		_, _ = fmt.Printf("Load %s is not delivered\n", n)
+	}
+
+	if !report.Valid() {
+		_, _ = fmt.Println("INVALID")
+		return 1
+	}
+	_, _ = fmt.Println("VALID")
+	return 0
+}
@@ -1,3 +1,5 @@
 module sched
 
 go 1.21.1
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,449 @@
+package models
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ALNSParams controls an adaptive large neighborhood search.  The search
+// stops when either the number of iterations or the time budget is used
+// up, so at least one of them should be set.
+type ALNSParams struct {
+	// Iterations is the number of destroy and repair steps, or 0 for no limit
+	Iterations int
+	// Budget is the wall-clock time to spend, or 0 for no limit
+	Budget time.Duration
+	// MinRemoval and MaxRemoval bound the number of loads removed by each destroy step
+	MinRemoval int
+	MaxRemoval int
+	// InitialTemperature and CoolingRate control the acceptance of repaired
+	// solutions that are worse than the current one, as in Anneal
+	InitialTemperature float64
+	CoolingRate        float64
+	// SegmentLength is the number of iterations between updates of the operator weights
+	SegmentLength int
+	// ReactionFactor sets how quickly operator weights follow recent success, from 0 to 1
+	ReactionFactor float64
+}
+
+// DefaultALNSParams returns parameters that suit problems of a few hundred loads
+func DefaultALNSParams() ALNSParams {
+	return ALNSParams{
+		Iterations:         2000,
+		MinRemoval:         4,
+		MaxRemoval:         30,
+		InitialTemperature: 50,
+		CoolingRate:        0.998,
+		SegmentLength:      100,
+		ReactionFactor:     0.1,
+	}
+}
+
+// Scores awarded to the operators that produced a repaired solution
+const (
+	scoreNewBest  = 33
+	scoreBetter   = 9
+	scoreAccepted = 13
+)
+
+// shawRandomness and worstRandomness bias the choice of loads to remove
+// towards the most related and the most costly loads, respectively
+const (
+	shawRandomness  = 6
+	worstRandomness = 3
+)
+
+// destroyOperator takes up to count loads out of the solution and returns them
+type destroyOperator func(count int) []*Load
+
+// repairOperator puts all of the given loads back into the solution
+type repairOperator func(loads []*Load)
+
+// operatorWeights keeps the adaptive weights of a set of operators
+type operatorWeights struct {
+	weights []float64
+	scores  []float64
+	uses    []int
+}
+
+func newOperatorWeights(size int) *operatorWeights {
+	w := &operatorWeights{
+		weights: make([]float64, size),
+		scores:  make([]float64, size),
+		uses:    make([]int, size),
+	}
+	for i := range w.weights {
+		w.weights[i] = 1
+	}
+	return w
+}
+
+// choose picks an operator at random, in proportion to the weights
+func (w *operatorWeights) choose(rng *rand.Rand) int {
+	total := 0.0
+	for _, weight := range w.weights {
+		total += weight
+	}
+	r := rng.Float64() * total
+	for i, weight := range w.weights {
+		r -= weight
+		if r < 0 {
+			return i
+		}
+	}
+	return len(w.weights) - 1
+}
+
+// reward adds to the score of an operator for the current segment
+func (w *operatorWeights) reward(i int, score float64) {
+	w.scores[i] += score
+}
+
+// update moves each weight towards the average score the operator earned
+// over the last segment, and starts a new segment
+func (w *operatorWeights) update(reaction float64) {
+	for i := range w.weights {
+		if w.uses[i] > 0 {
+			w.weights[i] = (1-reaction)*w.weights[i] + reaction*w.scores[i]/float64(w.uses[i])
+		}
+		w.scores[i] = 0
+		w.uses[i] = 0
+	}
+}
+
+// ALNS improves a complete solution by adaptive large neighborhood search.
+// Each iteration removes part of the solution (random, worst-cost, related
+// or whole-route removal) and reinserts the removed loads (greedy, regret-2
+// or regret-3 insertion).  Operators are chosen with weights that adapt to
+// how often they have led to better solutions, and worse solutions are
+// accepted as in simulated annealing.  The stable is left holding the
+// cheapest solution seen during the search, which ends early when ctx is done.
+func (s *DriverStable) ALNS(ctx context.Context, params ALNSParams) {
+	var deadline time.Time
+	if params.Budget > 0 {
+		deadline = time.Now().Add(params.Budget)
+	}
+
+	destroyers := []destroyOperator{
+		s.randomRemoval,
+		s.worstRemoval,
+		s.shawRemoval,
+		s.routeRemoval,
+	}
+	repairers := []repairOperator{
+		s.greedyInsertion,
+		func(loads []*Load) { s.regretInsertion(loads, 2) },
+		func(loads []*Load) { s.regretInsertion(loads, 3) },
+	}
+	destroyWeights := newOperatorWeights(len(destroyers))
+	repairWeights := newOperatorWeights(len(repairers))
+
+	currentRoutes := s.routes()
+	current := s.CalculateCost()
+	bestRoutes := currentRoutes
+	best := current
+	temperature := params.InitialTemperature
+
+	size := s.loadset.Size()
+	maxRemoval := params.MaxRemoval
+	if maxRemoval > size {
+		maxRemoval = size
+	}
+	minRemoval := params.MinRemoval
+	if minRemoval < 1 {
+		minRemoval = 1
+	}
+	if minRemoval > maxRemoval {
+		minRemoval = maxRemoval
+	}
+	if maxRemoval == 0 {
+		return
+	}
+
+	for i := 0; params.Iterations == 0 || i < params.Iterations; i++ {
+		if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+			break
+		}
+
+		d, r := destroyWeights.choose(s.rng), repairWeights.choose(s.rng)
+		destroyWeights.uses[d]++
+		repairWeights.uses[r]++
+
+		count := minRemoval + s.rng.Intn(maxRemoval-minRemoval+1)
+		removed := destroyers[d](count)
+		repairers[r](removed)
+		s.dismissIdleDrivers()
+
+		cost := s.CalculateCost()
+		var score float64
+		switch {
+		case cost < best:
+			score = scoreNewBest
+			best = cost
+			bestRoutes = s.routes()
+		case cost < current:
+			score = scoreBetter
+		case temperature > 0 && s.rng.Float64() < math.Exp((current-cost)/temperature):
+			score = scoreAccepted
+		}
+
+		if score > 0 {
+			current = cost
+			currentRoutes = s.routes()
+		} else {
+			s.setRoutes(currentRoutes)
+		}
+		destroyWeights.reward(d, score)
+		repairWeights.reward(r, score)
+
+		temperature *= params.CoolingRate
+		if params.SegmentLength > 0 && (i+1)%params.SegmentLength == 0 {
+			destroyWeights.update(params.ReactionFactor)
+			repairWeights.update(params.ReactionFactor)
+		}
+	}
+
+	s.setRoutes(bestRoutes)
+}
+
+// removeLoad takes the load at position i off the route of the driver
+func (d *Driver) removeLoad(i int) *Load {
+	load := d.completedLoads[i]
+	d.setRoute(join(d.completedLoads[:i], d.completedLoads[i+1:]))
+	return load
+}
+
+// removeLoads takes each of the given loads off whichever route it is on
+func (s *DriverStable) removeLoads(loads []*Load) {
+	remove := make(map[*Load]bool, len(loads))
+	for _, load := range loads {
+		remove[load] = true
+	}
+	for _, d := range s.dispatchedDrivers {
+		kept := []*Load{}
+		for _, load := range d.completedLoads {
+			if !remove[load] {
+				kept = append(kept, load)
+			}
+		}
+		if len(kept) != len(d.completedLoads) {
+			d.setRoute(kept)
+		}
+	}
+}
+
+// assignedLoads lists the loads currently on the routes of the drivers
+func (s *DriverStable) assignedLoads() []*Load {
+	loads := []*Load{}
+	for _, d := range s.dispatchedDrivers {
+		loads = append(loads, d.completedLoads...)
+	}
+	return loads
+}
+
+// randomRemoval removes loads chosen at random
+func (s *DriverStable) randomRemoval(count int) []*Load {
+	loads := s.assignedLoads()
+	s.rng.Shuffle(len(loads), func(i, j int) {
+		loads[i], loads[j] = loads[j], loads[i]
+	})
+	if count > len(loads) {
+		count = len(loads)
+	}
+	removed := loads[:count]
+	s.removeLoads(removed)
+	return removed
+}
+
+// worstRemoval removes the loads whose removal shortens their routes the most,
+// with some randomness so that repeated calls do not always remove the same loads
+func (s *DriverStable) worstRemoval(count int) []*Load {
+	removed := []*Load{}
+	for len(removed) < count {
+		type candidate struct {
+			driver *Driver
+			index  int
+			saving float64
+		}
+		candidates := []candidate{}
+		for _, d := range s.dispatchedDrivers {
+			for i := range d.completedLoads {
+				saving := -s.loadset.removalMinutes(d.completedLoads, i)
+				candidates = append(candidates, candidate{d, i, saving})
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].saving != candidates[b].saving {
+				return candidates[a].saving > candidates[b].saving
+			}
+			return candidates[a].driver.completedLoads[candidates[a].index].number <
+				candidates[b].driver.completedLoads[candidates[b].index].number
+		})
+
+		c := candidates[biasedIndex(s.rng, len(candidates), worstRandomness)]
+		removed = append(removed, c.driver.removeLoad(c.index))
+	}
+	return removed
+}
+
+// shawRemoval removes loads that are related to each other, measured by how
+// close the dropoff of each load is to the pickup of the other, so that the
+// repair step can rearrange loads that could share a route
+func (s *DriverStable) shawRemoval(count int) []*Load {
+	loads := s.assignedLoads()
+	if len(loads) == 0 {
+		return nil
+	}
+	if count > len(loads) {
+		count = len(loads)
+	}
+
+	matrix := s.loadset.Matrix
+	seed := loads[s.rng.Intn(len(loads))]
+	removed := []*Load{seed}
+	removedSet := map[*Load]bool{seed: true}
+	for len(removed) < count {
+		from := removed[s.rng.Intn(len(removed))]
+
+		type candidate struct {
+			load     *Load
+			distance float64
+		}
+		candidates := []candidate{}
+		for _, load := range loads {
+			if !removedSet[load] {
+				distance := matrix[from.number][load.number] + matrix[load.number][from.number]
+				candidates = append(candidates, candidate{load, distance})
+			}
+		}
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].distance != candidates[b].distance {
+				return candidates[a].distance < candidates[b].distance
+			}
+			return candidates[a].load.number < candidates[b].load.number
+		})
+
+		next := candidates[biasedIndex(s.rng, len(candidates), shawRandomness)].load
+		removed = append(removed, next)
+		removedSet[next] = true
+	}
+
+	s.removeLoads(removed)
+	return removed
+}
+
+// routeRemoval removes every load of a randomly chosen route.  The count
+// is ignored, as the size of the route decides how many loads are removed.
+func (s *DriverStable) routeRemoval(int) []*Load {
+	d := s.randomLoaded()
+	if d == nil {
+		return nil
+	}
+	removed := d.completedLoads
+	d.setRoute([]*Load{})
+	return removed
+}
+
+// biasedIndex picks an index below size, favoring the lowest indices more
+// strongly the larger the randomness exponent is
+func biasedIndex(rng *rand.Rand, size int, randomness float64) int {
+	return int(math.Pow(rng.Float64(), randomness) * float64(size))
+}
+
+// insertion is a place where a load can be inserted into the solution
+type insertion struct {
+	driver *Driver
+	index  int
+	// cost is the increase in the cost of the solution
+	cost float64
+}
+
+// insertions finds, for each active driver, the cheapest position at which
+// the load can be inserted without exceeding the shift limit, along with the
+// option of giving the load to a new driver.  They are sorted by cost.
+func (s *DriverStable) insertions(load *Load) []insertion {
+	options := []insertion{}
+	for _, d := range s.dispatchedDrivers {
+		if len(d.completedLoads) == 0 {
+			continue
+		}
+		best := insertion{index: -1, cost: math.Inf(1)}
+		for j := 0; j <= len(d.completedLoads); j++ {
+			increase := s.loadset.insertionMinutes(d.completedLoads, j, load)
+			if s.loadset.costs.PerMinute*increase < best.cost && s.loadset.withinShift(d.shiftMinutes+increase) {
+				best = insertion{driver: d, index: j, cost: s.loadset.costs.PerMinute * increase}
+			}
+		}
+		if best.index >= 0 {
+			options = append(options, best)
+		}
+	}
+
+	// A new driver can always take the load
+	options = append(options, insertion{
+		index: 0,
+		cost:  s.loadset.costs.PerDriver + s.loadset.costs.PerMinute*s.loadset.routeMinutes([]*Load{load}),
+	})
+
+	sort.SliceStable(options, func(a, b int) bool {
+		return options[a].cost < options[b].cost
+	})
+	return options
+}
+
+// insert applies an insertion, dispatching a new driver if it has none
+func (s *DriverStable) insert(load *Load, in insertion) {
+	d := in.driver
+	if d == nil {
+		d = s.DispatchNewDriver()
+	}
+	d.setRoute(join(d.completedLoads[:in.index], []*Load{load}, d.completedLoads[in.index:]))
+}
+
+// greedyInsertion repeatedly inserts whichever load can be inserted most cheaply
+func (s *DriverStable) greedyInsertion(loads []*Load) {
+	s.regretInsertion(loads, 1)
+}
+
+// regretInsertion repeatedly inserts the load that would cost the most to
+// leave for later: the one with the largest total difference between its
+// cheapest insertion and its next k-1 cheapest insertions on other routes.
+// Loads with fewer than k options go first.  With k of 1, this is the greedy
+// insertion of the cheapest load.
+func (s *DriverStable) regretInsertion(loads []*Load, k int) {
+	pending := append([]*Load{}, loads...)
+	for len(pending) > 0 {
+		chosen := -1
+		var chosenOption insertion
+		bestRegret := math.Inf(-1)
+		for i, load := range pending {
+			options := s.insertions(load)
+
+			var regret float64
+			if k == 1 {
+				regret = -options[0].cost
+			} else if len(options) < k {
+				regret = math.Inf(1)
+			} else {
+				for h := 1; h < k; h++ {
+					regret += options[h].cost - options[0].cost
+				}
+			}
+
+			if regret > bestRegret {
+				chosen = i
+				chosenOption = options[0]
+				bestRegret = regret
+			}
+		}
+
+		s.insert(pending[chosen], chosenOption)
+		pending = append(pending[:chosen], pending[chosen+1:]...)
+	}
+}
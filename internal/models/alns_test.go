@@ -0,0 +1,140 @@
+package models
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// farLoads holds load 1 on a route of its own east of home, load 2 far beyond
+// it and load 3 on the way there, where a driver cannot take both 1 and 2
+func farLoads() *DriverStable {
+	loadset := newTestLoadSet(
+		[4]float64{50, 0, 50, 5},
+		[4]float64{200, 0, 205, 0},
+		[4]float64{100, 0, 105, 0},
+	)
+	loadset.SetConstraints(Constraints{MaxShiftMinutes: 412})
+	return newTestStable(loadset, []int{1})
+}
+
+func TestRegretInsertion(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		k    int
+		want []string
+	}{
+		// Load 3 is the cheapest to insert, so it goes on the route of load 1,
+		// leaving load 2 to a driver of its own
+		{"greedy", 1, []string{"3,1", "2"}},
+		// Load 2 has no option but a new driver, so it goes first, and load 3
+		// is then inserted on its way
+		{"regret-2", 2, []string{"1", "3,2"}},
+		{"regret-3", 3, []string{"1", "3,2"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stable := farLoads()
+			stable.regretInsertion([]*Load{stable.loadset.LoadMap[2], stable.loadset.LoadMap[3]}, tt.k)
+
+			got := stable.Solution()
+			if len(got) != len(tt.want) {
+				t.Fatalf("wrong routes.  wanted=%v, got=%v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("wrong routes.  wanted=%v, got=%v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	// Greedy insertion is regret insertion with k of 1
+	stable := farLoads()
+	stable.greedyInsertion([]*Load{stable.loadset.LoadMap[2], stable.loadset.LoadMap[3]})
+	if got := stable.Solution(); len(got) != 2 || got[0] != "3,1" || got[1] != "2" {
+		t.Fatalf("wrong routes after greedy insertion, got %v", got)
+	}
+}
+
+func TestRemovalCounts(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		remove func(s *DriverStable, count int) []*Load
+	}{
+		{"random", (*DriverStable).randomRemoval},
+		{"worst", (*DriverStable).worstRemoval},
+		{"shaw", (*DriverStable).shawRemoval},
+	} {
+		for count := 1; count <= 6; count++ {
+			stable := newTestStable(twoClusters(), []int{1, 2, 3}, []int{4, 5, 6})
+			removed := tt.remove(stable, count)
+			if len(removed) != count {
+				t.Fatalf("%s: wrong number of loads removed.  wanted=%d, got=%d", tt.name, count, len(removed))
+			}
+
+			// Every load is either removed or on a route, and never both
+			seen := map[*Load]bool{}
+			for _, load := range append(removed, stable.assignedLoads()...) {
+				if seen[load] {
+					t.Fatalf("%s: load %s removed and routed, or removed twice", tt.name, load.ID)
+				}
+				seen[load] = true
+			}
+			if len(seen) != 6 {
+				t.Fatalf("%s: loads went missing, got %d of 6", tt.name, len(seen))
+			}
+
+			// The shift of each route is that of its remaining loads
+			for _, d := range stable.dispatchedDrivers {
+				if minutes := stable.loadset.routeMinutes(d.completedLoads); d.shiftMinutes != minutes {
+					t.Fatalf("%s: route '%s' should take %v minutes, got %v", tt.name, d.completedLoadString(), minutes, d.shiftMinutes)
+				}
+			}
+		}
+	}
+}
+
+func TestALNSKeepsBest(t *testing.T) {
+	// As for annealing: a driver can take a load or two from the other
+	// cluster, but not all of them.  Starting from the best solution, with
+	// a temperature hot enough to accept most worse ones, the search can only
+	// end where it started.
+	for seed := int64(1); seed <= 5; seed++ {
+		stable := newTestStable(twoClusters(), []int{1, 2, 3}, []int{4, 5, 6})
+		stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 225})
+		stable.rng = rand.New(rand.NewSource(seed))
+		before := stable.CalculateCost()
+
+		params := DefaultALNSParams()
+		params.Iterations = 300
+		params.InitialTemperature = 10000
+		params.CoolingRate = 1
+		stable.ALNS(context.Background(), params)
+
+		if after := stable.CalculateCost(); after > before {
+			t.Fatalf("seed %d: cost went up.  before=%v, after=%v", seed, before, after)
+		}
+		if total, unique := stable.Size(); total != 6 || unique != 6 {
+			t.Fatalf("seed %d: wrong loads delivered.  total=%d, unique=%d", seed, total, unique)
+		}
+	}
+}
+
+func TestALNSLowersCost(t *testing.T) {
+	stable := newTestStable(twoClusters(), []int{1}, []int{2}, []int{3}, []int{4}, []int{5}, []int{6})
+	stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 150})
+	before := stable.CalculateCost()
+
+	params := DefaultALNSParams()
+	params.Iterations = 300
+	stable.ALNS(context.Background(), params)
+
+	if after := stable.CalculateCost(); after >= before {
+		t.Fatalf("cost should have gone down.  before=%v, after=%v", before, after)
+	}
+	for _, d := range stable.dispatchedDrivers {
+		if d.shiftMinutes > stable.ShiftLimit() {
+			t.Fatalf("route '%s' takes %v minutes, over the limit of %v", d.completedLoadString(), d.shiftMinutes, stable.ShiftLimit())
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// AnnealSchedule controls a simulated annealing run.  The run stops when
+// either the number of iterations or the time budget is used up, so at
+// least one of them should be set.
+type AnnealSchedule struct {
+	// InitialTemperature is the starting temperature, in units of cost.  A move
+	// that raises the cost by this much is accepted with a probability of 1/e.
+	InitialTemperature float64
+	// CoolingRate is the factor applied to the temperature after each iteration
+	CoolingRate float64
+	// Iterations is the number of moves to try, or 0 for no limit
+	Iterations int
+	// Budget is the wall-clock time to spend, or 0 for no limit
+	Budget time.Duration
+}
+
+// DefaultAnnealSchedule returns a schedule that suits problems of a few hundred loads
+func DefaultAnnealSchedule() AnnealSchedule {
+	return AnnealSchedule{
+		InitialTemperature: 100,
+		CoolingRate:        0.9995,
+		Iterations:         20000,
+	}
+}
+
+// routeChange remembers the route a driver had before a move,
+// so that the move can be undone if it is rejected
+type routeChange struct {
+	driver *Driver
+	loads  []*Load
+}
+
+// Anneal improves a complete solution by simulated annealing.  Random moves
+// (relocating a load, swapping two loads or dissolving a whole route) that keep
+// every changed route within the shift limit are accepted if they lower the cost,
+// and otherwise with a probability that falls as the temperature cools.  The
+// stable is left holding the cheapest solution seen during the run, which
+// ends early when ctx is done.
+func (s *DriverStable) Anneal(ctx context.Context, schedule AnnealSchedule) {
+	var deadline time.Time
+	if schedule.Budget > 0 {
+		deadline = time.Now().Add(schedule.Budget)
+	}
+
+	moves := []func() []routeChange{
+		s.randomRelocate,
+		s.randomSwap,
+		s.randomRouteRemoval,
+	}
+
+	current := s.CalculateCost()
+	best := current
+	bestRoutes := s.routes()
+	temperature := schedule.InitialTemperature
+
+	for i := 0; schedule.Iterations == 0 || i < schedule.Iterations; i++ {
+		if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+			break
+		}
+
+		changes := moves[s.rng.Intn(len(moves))]()
+		if changes != nil {
+			cost := s.CalculateCost()
+			delta := cost - current
+			if delta <= 0 || (temperature > 0 && s.rng.Float64() < math.Exp(-delta/temperature)) {
+				current = cost
+				if current < best {
+					best = current
+					bestRoutes = s.routes()
+				}
+			} else {
+				undo(changes)
+			}
+		}
+
+		temperature *= schedule.CoolingRate
+	}
+
+	s.setRoutes(bestRoutes)
+}
+
+// routes returns the loads of each active driver
+func (s *DriverStable) routes() [][]*Load {
+	routes := [][]*Load{}
+	for _, d := range s.dispatchedDrivers {
+		if len(d.completedLoads) > 0 {
+			routes = append(routes, d.completedLoads)
+		}
+	}
+	return routes
+}
+
+// setRoutes replaces the drivers of the stable with one driver per route
+func (s *DriverStable) setRoutes(routes [][]*Load) {
+	s.dispatchedDrivers = []*Driver{}
+	for _, route := range routes {
+		s.DispatchNewDriver().setRoute(route)
+	}
+}
+
+// undo puts back the routes that were changed by a move
+func undo(changes []routeChange) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		changes[i].driver.setRoute(changes[i].loads)
+	}
+}
+
+// randomLoaded picks a random driver that has at least one load, or nil if there is none
+func (s *DriverStable) randomLoaded() *Driver {
+	loaded := []*Driver{}
+	for _, d := range s.dispatchedDrivers {
+		if len(d.completedLoads) > 0 {
+			loaded = append(loaded, d)
+		}
+	}
+	if len(loaded) == 0 {
+		return nil
+	}
+	return loaded[s.rng.Intn(len(loaded))]
+}
+
+// randomRelocate moves a random load to a random position on the route
+// of another random driver.  It returns the changes made, or nil if the
+// move was not possible.
+func (s *DriverStable) randomRelocate() []routeChange {
+	a, b := s.randomLoaded(), s.randomLoaded()
+	if a == nil || a == b {
+		return nil
+	}
+	aLoads, bLoads := a.completedLoads, b.completedLoads
+	i, j := s.rng.Intn(len(aLoads)), s.rng.Intn(len(bLoads)+1)
+
+	newA := join(aLoads[:i], aLoads[i+1:])
+	newB := join(bLoads[:j], aLoads[i:i+1], bLoads[j:])
+	if !s.loadset.withinShift(s.loadset.routeMinutes(newA)) || !s.loadset.withinShift(s.loadset.routeMinutes(newB)) {
+		return nil
+	}
+
+	a.setRoute(newA)
+	b.setRoute(newB)
+	return []routeChange{{a, aLoads}, {b, bLoads}}
+}
+
+// randomSwap exchanges random loads between the routes of two random drivers.
+// It returns the changes made, or nil if the move was not possible.
+func (s *DriverStable) randomSwap() []routeChange {
+	a, b := s.randomLoaded(), s.randomLoaded()
+	if a == nil || a == b {
+		return nil
+	}
+	aLoads, bLoads := a.completedLoads, b.completedLoads
+	i, j := s.rng.Intn(len(aLoads)), s.rng.Intn(len(bLoads))
+
+	newA := join(aLoads[:i], bLoads[j:j+1], aLoads[i+1:])
+	newB := join(bLoads[:j], aLoads[i:i+1], bLoads[j+1:])
+	if !s.loadset.withinShift(s.loadset.routeMinutes(newA)) || !s.loadset.withinShift(s.loadset.routeMinutes(newB)) {
+		return nil
+	}
+
+	a.setRoute(newA)
+	b.setRoute(newB)
+	return []routeChange{{a, aLoads}, {b, bLoads}}
+}
+
+// randomRouteRemoval takes every load away from a random driver and inserts
+// each of them, in random order, at the cheapest position on the routes of the
+// other drivers.  It returns the changes made, or nil if some load could not
+// be placed anywhere without exceeding the shift limit.
+func (s *DriverStable) randomRouteRemoval() []routeChange {
+	removed := s.randomLoaded()
+	if removed == nil {
+		return nil
+	}
+	loads := removed.completedLoads
+	changes := []routeChange{{removed, loads}}
+	removed.setRoute([]*Load{})
+
+	for _, k := range s.rng.Perm(len(loads)) {
+		load := []*Load{loads[k]}
+
+		var bestDriver *Driver
+		var bestRoute []*Load
+		bestIncrease := math.Inf(1)
+		for _, d := range s.dispatchedDrivers {
+			if len(d.completedLoads) == 0 {
+				continue
+			}
+			for j := 0; j <= len(d.completedLoads); j++ {
+				minutes := s.loadset.routeMinutes(d.completedLoads[:j], load, d.completedLoads[j:])
+				increase := minutes - d.shiftMinutes
+				if s.loadset.withinShift(minutes) && increase < bestIncrease {
+					bestDriver = d
+					bestRoute = join(d.completedLoads[:j], load, d.completedLoads[j:])
+					bestIncrease = increase
+				}
+			}
+		}
+
+		if bestDriver == nil {
+			undo(changes)
+			return nil
+		}
+		changes = append(changes, routeChange{bestDriver, bestDriver.completedLoads})
+		bestDriver.setRoute(bestRoute)
+	}
+
+	return changes
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// snapshot records the route and shift of every driver of the stable
+func snapshot(s *DriverStable) []string {
+	routes := make([]string, len(s.dispatchedDrivers))
+	for i, d := range s.dispatchedDrivers {
+		routes[i] = d.completedLoadString() + " " + strconv.FormatFloat(d.shiftMinutes, 'f', -1, 64)
+	}
+	return routes
+}
+
+func TestAnnealNeverCostlier(t *testing.T) {
+	// A driver can take a load or two from the other cluster, but not all of
+	// them, so the run always keeps two drivers.  Starting from the best of
+	// those solutions, at a temperature hot enough to accept most moves that
+	// raise the cost, the run can only end where it started.
+	for seed := int64(1); seed <= 5; seed++ {
+		stable := newTestStable(twoClusters(), []int{1, 2, 3}, []int{4, 5, 6})
+		stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 225})
+		stable.rng = rand.New(rand.NewSource(seed))
+		before := stable.CalculateCost()
+
+		stable.Anneal(context.Background(), AnnealSchedule{InitialTemperature: 10000, CoolingRate: 1, Iterations: 500})
+
+		if after := stable.CalculateCost(); after > before {
+			t.Fatalf("seed %d: cost went up.  before=%v, after=%v", seed, before, after)
+		}
+		if total, unique := stable.Size(); total != 6 || unique != 6 {
+			t.Fatalf("seed %d: wrong loads delivered.  total=%d, unique=%d", seed, total, unique)
+		}
+	}
+}
+
+func TestAnnealLowersCost(t *testing.T) {
+	stable := newTestStable(twoClusters(), []int{1}, []int{2}, []int{3}, []int{4}, []int{5}, []int{6})
+	stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 150})
+	before := stable.CalculateCost()
+
+	stable.Anneal(context.Background(), AnnealSchedule{InitialTemperature: 50, CoolingRate: 0.99, Iterations: 2000})
+
+	if after := stable.CalculateCost(); after >= before {
+		t.Fatalf("cost should have gone down.  before=%v, after=%v", before, after)
+	}
+	for _, d := range stable.dispatchedDrivers {
+		if d.shiftMinutes > stable.ShiftLimit() {
+			t.Fatalf("route '%s' takes %v minutes, over the limit of %v", d.completedLoadString(), d.shiftMinutes, stable.ShiftLimit())
+		}
+	}
+}
+
+func TestUndoRouteRemoval(t *testing.T) {
+	// When the first route is removed, both of its loads go to the driver of
+	// load 3, which is then changed twice
+	changedTwice := false
+	for seed := int64(1); seed <= 20; seed++ {
+		stable := newTestStable(twoClusters(), []int{1, 2}, []int{3}, []int{4, 5, 6})
+		stable.rng = rand.New(rand.NewSource(seed))
+		before := snapshot(stable)
+
+		changes := stable.randomRouteRemoval()
+		if changes == nil {
+			t.Fatalf("seed %d: should have placed every load", seed)
+		}
+		seen := map[*Driver]bool{}
+		for _, c := range changes {
+			changedTwice = changedTwice || seen[c.driver]
+			seen[c.driver] = true
+		}
+
+		undo(changes)
+		after := snapshot(stable)
+		for i := range before {
+			if after[i] != before[i] {
+				t.Fatalf("seed %d: driver %d not restored.  wanted='%s', got='%s'", seed, i, before[i], after[i])
+			}
+		}
+	}
+	if !changedTwice {
+		t.Fatal("no removal changed a driver twice")
+	}
+
+	// A load that fits nowhere else leaves every route as it was
+	stable := newTestStable(twoClusters(), []int{1, 2, 3}, []int{4, 5, 6})
+	stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 150})
+	before := snapshot(stable)
+	if stable.randomRouteRemoval() != nil {
+		t.Fatal("should not have removed a route")
+	}
+	for i, route := range snapshot(stable) {
+		if route != before[i] {
+			t.Fatalf("driver %d not restored.  wanted='%s', got='%s'", i, before[i], route)
+		}
+	}
+}
+
+func TestRandomMovesWithinShift(t *testing.T) {
+	// A driver can finish either cluster, but not both, within the shift
+	stable := newTestStable(twoClusters(), []int{1}, []int{2}, []int{3}, []int{4}, []int{5}, []int{6})
+	stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 150})
+
+	// Accept every move, as the hottest anneal might
+	moves := []func() []routeChange{stable.randomRelocate, stable.randomSwap, stable.randomRouteRemoval}
+	for i := 0; i < 1000; i++ {
+		moves[stable.rng.Intn(len(moves))]()
+		for _, d := range stable.dispatchedDrivers {
+			if d.shiftMinutes > stable.ShiftLimit() {
+				t.Fatalf("route '%s' takes %v minutes, over the limit of %v", d.completedLoadString(), d.shiftMinutes, stable.ShiftLimit())
+			}
+		}
+	}
+	if total, unique := stable.Size(); total != 6 || unique != 6 {
+		t.Fatalf("wrong loads delivered.  total=%d, unique=%d", total, unique)
+	}
+}
@@ -3,22 +3,26 @@
 package models
 
 const (
-	lparen                     = '('
-	rparen                     = ')'
-	maxDriverHours      uint64 = 12
-	maxDriverMinutes    uint64 = 60
-	maxDriverSqDistance uint64 = maxDriverHours * maxDriverHours * maxDriverMinutes * maxDriverMinutes
-	// MaxNearestNeighbors controls how many possible paths are tested in the solution.  If this
-	// value is larger than the size of the load set, then the size of the load set will be used.
-	MaxNearestNeighbors        = 10
-	costPerDriver       uint64 = 500
-	costPerDist         uint64 = 1
+	lparen                   = '('
+	rparen                   = ')'
+	maxDriverHours   float64 = 12
+	maxDriverMinutes float64 = 60
+	// maxShiftMinutes is the default for the longest a driver may drive in a shift.
+	// One unit of distance takes one minute to drive, so this is also the longest route.
+	maxShiftMinutes float64 = maxDriverHours * maxDriverMinutes
+	// MaxNearestNeighbors is the default number of nearest pickups that a driver chooses
+	// between, and of each kind of start of a nearest neighbor walk.  If this value is
+	// larger than the size of the load set, then the size of the load set will be used.
+	MaxNearestNeighbors = 10
+	// costPerDriver and costPerDist are the default prices of a driver and of a minute driven
+	costPerDriver float64 = 500
+	costPerDist   float64 = 1
 )
 
 var (
 	comma    = []byte(",")
-	origin   = newLocation(Home, 0, 0)
-	homeLoad = NewLoad(0, origin, origin, true)
+	origin   = NewLocation(Home, 0, 0)
+	homeLoad = NewLoad("", origin, origin, true)
 )
 
 type locationType string
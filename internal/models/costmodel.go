@@ -0,0 +1,55 @@
+package models
+
+import "fmt"
+
+// CostModel prices the solutions of a load set
+type CostModel struct {
+	// PerDriver is the fixed cost of each driver that completes a load
+	PerDriver float64
+	// PerMinute is the cost of each minute driven (one unit of distance
+	// takes one minute to drive, so this is also the cost per unit of distance)
+	PerMinute float64
+}
+
+// DefaultCostModel returns the cost model used unless another is set
+func DefaultCostModel() CostModel {
+	return CostModel{
+		PerDriver: costPerDriver,
+		PerMinute: costPerDist,
+	}
+}
+
+// Cost calculates the cost of a solution using the given number
+// of drivers to drive the given total number of minutes
+func (c CostModel) Cost(drivers int, totalMinutes float64) float64 {
+	return c.PerDriver*float64(drivers) + c.PerMinute*totalMinutes
+}
+
+// Validate checks that none of the costs are negative
+func (c CostModel) Validate() error {
+	if c.PerDriver < 0 || c.PerMinute < 0 {
+		return fmt.Errorf("costs cannot be negative, got %v per driver and %v per minute", c.PerDriver, c.PerMinute)
+	}
+	return nil
+}
+
+// Constraints limit the shifts that drivers may work
+type Constraints struct {
+	// MaxShiftMinutes is the longest shift a driver may work, including the drive home
+	MaxShiftMinutes float64
+}
+
+// DefaultConstraints returns the constraints used unless others are set
+func DefaultConstraints() Constraints {
+	return Constraints{
+		MaxShiftMinutes: maxShiftMinutes,
+	}
+}
+
+// Validate checks that a shift can last some time
+func (c Constraints) Validate() error {
+	if c.MaxShiftMinutes <= 0 {
+		return fmt.Errorf("the shift limit must be positive, got %v minutes", c.MaxShiftMinutes)
+	}
+	return nil
+}
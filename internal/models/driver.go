@@ -2,42 +2,43 @@ package models
 
 import (
 	"math/rand"
-	"strconv"
 	"strings"
 )
 
 // Driver represents an individual Driver as part of a Driver stable
 // which is a group of drivers used to complete a load set
 type Driver struct {
-	network        *LoadSet
-	shiftSqDist    uint64
+	network *LoadSet
+	rng     *rand.Rand
+	// shiftMinutes is the time since the shift started, including any waiting
+	shiftMinutes   float64
 	load           *Load
 	completedLoads []*Load
 }
 
-func newDriver(network *LoadSet) *Driver {
+func newDriver(network *LoadSet, rng *rand.Rand) *Driver {
 	return &Driver{
 		network:        network,
+		rng:            rng,
 		load:           homeLoad,
 		completedLoads: []*Load{},
 	}
 }
 
 // FindNearestPickup is called in the solution algorithm to determine whether or not the
-// driver is able to find a pickup location for which it could complete the delivery and return home.
-// If this is not possible, this function returns false
-func (d *Driver) FindNearestPickup(choice int) bool {
+// driver is able to find a pickup location for which it could complete the delivery and return home,
+// choosing among the given number of nearest pickups.  If this is not possible, this function returns true
+func (d *Driver) FindNearestPickup(choice int, size int) bool {
 	// Find the current node number of the driver.  Because of the way things are written,
 	// the driver is at the dropoff point of this load.
 	current := d.load.number
-	size := d.network.size
 	// Create a new neighborhood that will hold the nearest uncompleted neighbors, up to
-	// a maximum of MaxNearestNeighbors.  Note that nearest is measured by the distance
+	// a maximum of size.  Note that nearest is measured by the distance
 	// between the current load's dropoff point and the neighbor's pickup point
-	neighbors := newNeighborhood()
+	neighbors := newNeighborhood(size)
 
 	// Cycle through all loads
-	for i := 0; i < size; i++ {
+	for i := 0; i < d.network.size; i++ {
 		// Do not include the current load in the list of neighbors
 		if i == current {
 			continue
@@ -57,12 +58,12 @@ func (d *Driver) FindNearestPickup(choice int) bool {
 		neighbors.insert(dist, load)
 	}
 
-	// Remove any nil neighbors (happens when there are not enough non-completed)
-	// loads to fill a slice of length MaxNearestNeighbors
-	neighbors.prune()
+	// Order the neighbors, nearest first.  There are fewer than size of them
+	// when there are not enough non-completed loads left.
+	nearest := neighbors.sorted()
 
 	// See how many nearest neighbors there are
-	numNeighbors := len(neighbors.neighbors)
+	numNeighbors := len(nearest)
 
 	// If there are no non-completed neighbors, we're done
 	if numNeighbors == 0 {
@@ -75,14 +76,14 @@ func (d *Driver) FindNearestPickup(choice int) bool {
 		choice = numNeighbors
 	} else if choice < 0 {
 		// For negative choices, choose a random nearest neighbor
-		choice = rand.Intn(numNeighbors)
+		choice = d.rng.Intn(numNeighbors)
 	}
 
 	// Check each neighbor, starting at the indicated value (choice)
 	// and find the first one that allows you to complete that load
 	// and still be able to get home if necessary.
 	for i := 0; i < numNeighbors; i++ {
-		neighbor := neighbors.neighbors[(choice+i)%numNeighbors]
+		neighbor := nearest[(choice+i)%numNeighbors]
 		if ok := d.testNeighbor(neighbor); ok {
 			d.driveNeighbor(neighbor)
 			return false
@@ -102,35 +103,41 @@ func (d *Driver) getCompletedLoadList() []*Load {
 func (d *Driver) completedLoadString() string {
 	c := make([]string, len(d.completedLoads))
 	for i, l := range d.completedLoads {
-		c[i] = strconv.Itoa(l.number)
+		c[i] = l.ID
 	}
 
 	return strings.Join(c, ",")
 }
 
+// setRoute replaces the loads completed by a driver that is back home,
+// and remeasures the shift accordingly
+func (d *Driver) setRoute(loads []*Load) {
+	d.completedLoads = loads
+	d.shiftMinutes = d.network.routeMinutes(loads)
+}
+
 // ReturnHome moves a driver from the dropoff location of the
 // current load back to the origin
 func (d *Driver) ReturnHome() {
-	d.shiftSqDist += d.network.Matrix[d.load.number][0]
+	d.shiftMinutes += d.network.Matrix[d.load.number][0]
 	d.load = homeLoad
 }
 
 // testNeighbor simply checks to see if the driver could
 // move from the current dropoff location to the neighboring
-// pickup location, deliver that load, and return home without
-// exceeding the shift limit.
+// pickup location, deliver that load within its windows, and
+// return home without exceeding the shift limit.
 func (d *Driver) testNeighbor(n *neighbor) bool {
-	return d.shiftSqDist+
-		n.dist+
-		d.network.Matrix[n.load.number][n.load.number]+
-		d.network.Matrix[0][n.load.number] < maxDriverSqDistance
+	clock, ok := d.network.deliver(d.shiftMinutes, d.load, n.load)
+	return ok && d.network.withinShift(clock+d.network.Matrix[n.load.number][0])
 }
 
 // driveNeighbor actually executes a movement from a point to a neighboring
 // pickup point, delivers the load, and sets the driver location to the
-// new dropoff point.
+// new dropoff point.  The shift includes any time spent waiting for the
+// windows of the load to open.
 func (d *Driver) driveNeighbor(n *neighbor) {
-	d.shiftSqDist += n.dist + d.network.Matrix[n.load.number][n.load.number]
+	d.shiftMinutes, _ = d.network.deliver(d.shiftMinutes, d.load, n.load)
 	d.load = n.load
 	n.load.complete = true
 	d.completedLoads = append(d.completedLoads, n.load)
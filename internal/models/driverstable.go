@@ -1,19 +1,23 @@
 package models
 
-import "math"
+import "math/rand"
 
 // DriverStable is a set of drivers (picture the TV show Taxi) that can be used
 // to completely deliver a load set
 type DriverStable struct {
 	loadset           *LoadSet
+	rng               *rand.Rand
 	dispatchedDrivers []*Driver
-	cost              uint64
+	cost              float64
 }
 
-// NewDriverStable is a factory function for creating a new stable of drivers
-func NewDriverStable(loadset *LoadSet) *DriverStable {
+// NewDriverStable is a factory function for creating a new stable of drivers.
+// All of the randomness used in building and improving the solution comes
+// from rng, which must not be shared with anything running concurrently.
+func NewDriverStable(loadset *LoadSet, rng *rand.Rand) *DriverStable {
 	return &DriverStable{
 		loadset:           loadset,
+		rng:               rng,
 		dispatchedDrivers: []*Driver{},
 	}
 }
@@ -21,25 +25,63 @@ func NewDriverStable(loadset *LoadSet) *DriverStable {
 // DispatchNewDriver creates a new driver whenever a previous driver has reached
 // its limit
 func (s *DriverStable) DispatchNewDriver() *Driver {
-	driver := newDriver(s.loadset)
+	driver := newDriver(s.loadset, s.rng)
 	s.dispatchedDrivers = append(s.dispatchedDrivers, driver)
 	return driver
 }
 
-// CalculateCost creates a close estimate of the cost of a particular solution.
-// Note that its an estimate because distances are calculated using rounded
-// integer locations rather than the full floating values given in the problem.
-// Statistically, the error should reduce toward zero as the problem set gets bigger.
-func (s *DriverStable) CalculateCost() uint64 {
-	drivers := uint64(len(s.dispatchedDrivers))
+// CalculateCost returns the cost of a particular solution under the cost model
+// of the load set: a fixed cost for each driver plus the cost of the total number
+// of minutes worked, where every leg of every route is measured as a straight
+// line between the locations given in the problem, and the time spent loading,
+// unloading and waiting for windows to open counts too.
+func (s *DriverStable) CalculateCost() float64 {
+	return s.loadset.costs.Cost(s.activeDrivers(), s.totalMinutes())
+}
+
+// ShiftLimit returns the longest shift, in minutes, that a driver may work
+func (s *DriverStable) ShiftLimit() float64 {
+	return s.loadset.ShiftLimit()
+}
+
+// totalMinutes adds up the length of the shifts of all of the drivers
+func (s *DriverStable) totalMinutes() float64 {
+	var totalMinutes float64
+	for _, d := range s.dispatchedDrivers {
+		totalMinutes += d.shiftMinutes
+	}
+	return totalMinutes
+}
 
-	var totalSqDist uint64
+// activeDrivers counts the dispatched drivers that have completed at least one load
+func (s *DriverStable) activeDrivers() int {
+	active := 0
 	for _, d := range s.dispatchedDrivers {
-		totalSqDist += d.shiftSqDist
+		active += occupied(len(d.completedLoads))
 	}
-	dist := uint64(math.Round(math.Sqrt(float64(totalSqDist))))
+	return active
+}
+
+// minImprovement is the smallest reduction in cost that counts as an
+// improvement, so that rounding errors cannot make a search go in circles
+const minImprovement = 1e-6
+
+// improves reports whether replacing routes that take before minutes in total
+// with routes that take after minutes lowers the cost of the solution, given
+// that the replacement changes the number of active drivers by delta.
+func (s *DriverStable) improves(before, after float64, delta int) bool {
+	return s.loadset.costs.PerDriver*float64(delta)+s.loadset.costs.PerMinute*(after-before) < -minImprovement
+}
 
-	return costPerDriver*drivers + costPerDist*dist
+// dismissIdleDrivers removes any drivers that no longer complete any loads
+func (s *DriverStable) dismissIdleDrivers() {
+	drivers := []*Driver{}
+	for _, d := range s.dispatchedDrivers {
+		if len(d.completedLoads) > 0 {
+			drivers = append(drivers, d)
+		}
+	}
+	s.dispatchedDrivers = drivers
 }
 
 // Solution returns a slice of the strings representing the routes of each driver
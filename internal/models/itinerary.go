@@ -0,0 +1,84 @@
+package models
+
+// LegType is what a driver is doing on a leg of a shift
+type LegType string
+
+const (
+	// ToPickup is a drive, empty, from home or a dropoff to the pickup of a load
+	ToPickup LegType = "to-pickup"
+	// Carrying is a drive with a load, from its pickup to its dropoff
+	Carrying LegType = "carrying"
+	// ToHome is the drive home, empty, at the end of the shift
+	ToHome LegType = "to-home"
+	// Waiting is time spent at a pickup or dropoff waiting for its window to open
+	Waiting LegType = "waiting"
+	// Loading is time spent loading at a pickup
+	Loading LegType = "loading"
+	// Unloading is time spent unloading at a dropoff
+	Unloading LegType = "unloading"
+)
+
+// Leg is a single drive of a driver's shift, or a stop between drives
+type Leg struct {
+	Type LegType
+	// Load is the ID of the load being driven to, carried, waited for,
+	// loaded or unloaded, and empty on the drive home
+	Load string
+	From *Location
+	To   *Location
+	// Start and End are the minutes at which the leg starts and ends, counting
+	// from the same time as the start of the shift
+	Start float64
+	End   float64
+}
+
+// Itineraries returns the legs of each driver's shift, in order, with the
+// shifts all starting at the given minute
+func (s *DriverStable) Itineraries(start float64) [][]Leg {
+	itineraries := make([][]Leg, len(s.dispatchedDrivers))
+	for i, d := range s.dispatchedDrivers {
+		itineraries[i] = s.loadset.Itinerary(d.completedLoads, start)
+	}
+	return itineraries
+}
+
+// Itinerary lays out the legs of a shift that starts at the given minute and
+// completes the loads in order, taking the length of each leg from the Matrix.
+// A wait is added wherever the driver arrives before a window opens, and a
+// stop for loading or unloading wherever that takes any time.  Windows
+// that are missed are not checked here, so the itinerary of an invalid route
+// shows when the driver arrives anyway.
+func (l *LoadSet) Itinerary(loads []*Load, start float64) []Leg {
+	legs := make([]Leg, 0, 4*len(loads)+1)
+	clock := start
+	prev := homeLoad
+	drive := func(t LegType, id string, from, to *Location, minutes float64) {
+		legs = append(legs, Leg{Type: t, Load: id, From: from, To: to, Start: clock, End: clock + minutes})
+		clock += minutes
+	}
+	wait := func(id string, at *Location, window Window) {
+		if opens := start + window.Earliest; clock < opens {
+			legs = append(legs, Leg{Type: Waiting, Load: id, From: at, To: at, Start: clock, End: opens})
+			clock = opens
+		}
+	}
+	stop := func(t LegType, id string, at *Location, minutes float64) {
+		if minutes > 0 {
+			legs = append(legs, Leg{Type: t, Load: id, From: at, To: at, Start: clock, End: clock + minutes})
+			clock += minutes
+		}
+	}
+	for _, load := range loads {
+		drive(ToPickup, load.ID, prev.Dropoff, load.Pickup, l.Matrix[prev.number][load.number])
+		wait(load.ID, load.Pickup, load.PickupWindow)
+		stop(Loading, load.ID, load.Pickup, l.loading(load))
+		drive(Carrying, load.ID, load.Pickup, load.Dropoff, l.Matrix[load.number][load.number])
+		wait(load.ID, load.Dropoff, load.DropoffWindow)
+		stop(Unloading, load.ID, load.Dropoff, l.unloading(load))
+		prev = load
+	}
+	if len(loads) > 0 {
+		drive(ToHome, "", prev.Dropoff, origin, l.Matrix[prev.number][0])
+	}
+	return legs
+}
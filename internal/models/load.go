@@ -1,18 +1,29 @@
 package models
 
 // Load represents a single load, holding the pickup and dropoff locations
-// and keeping track of whether or not it has been completed
+// and keeping track of whether or not it has been completed.  The ID is
+// whatever identifies the load in the problem file, while the number is
+// the load's dense index within its LoadSet, assigned by AddLoad.
 type Load struct {
-	number   int
-	Pickup   *Location
-	Dropoff  *Location
-	complete bool
+	ID      string
+	number  int
+	Pickup  *Location
+	Dropoff *Location
+	// PickupWindow and DropoffWindow limit when the load may be picked up
+	// and dropped off.  They allow any time unless set.
+	PickupWindow  Window
+	DropoffWindow Window
+	// Loading and Unloading are the minutes spent at the pickup and at the
+	// dropoff of this load, or nil to take the default of the LoadSet
+	Loading   *float64
+	Unloading *float64
+	complete  bool
 }
 
 // NewLoad is a factory function used in creating a LoadSet when reading a problem file
-func NewLoad(num int, pickup *Location, dropoff *Location, complete bool) *Load {
+func NewLoad(id string, pickup *Location, dropoff *Location, complete bool) *Load {
 	return &Load{
-		number:   num,
+		ID:       id,
 		Pickup:   pickup,
 		Dropoff:  dropoff,
 		complete: complete,
@@ -23,8 +34,13 @@ func NewLoad(num int, pickup *Location, dropoff *Location, complete bool) *Load
 // of the completion status of the original
 func (l *Load) clone() *Load {
 	return &Load{
-		number:  l.number,
-		Pickup:  l.Pickup,
-		Dropoff: l.Dropoff,
+		ID:            l.ID,
+		number:        l.number,
+		Pickup:        l.Pickup,
+		Dropoff:       l.Dropoff,
+		PickupWindow:  l.PickupWindow,
+		DropoffWindow: l.DropoffWindow,
+		Loading:       l.Loading,
+		Unloading:     l.Unloading,
 	}
 }
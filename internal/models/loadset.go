@@ -3,19 +3,34 @@ package models
 // LoadSet is the collection of loads that make up a full problem to be solved
 type LoadSet struct {
 	size int
-	// LoadMap maps the load number to the load struct
+	// LoadMap maps the load number to the load struct.  Load numbers run
+	// consecutively from 1, in the order the loads were added, with the
+	// origin as load 0.
 	LoadMap map[int]*Load
-	Matrix  [][]uint64
+	// ids maps the ID of each load to the load struct
+	ids map[string]*Load
+	// Matrix holds the minutes it takes to drive from the Dropoff of the
+	// row load to the Pickup of the column load
+	Matrix [][]float64
+	// rounded is set when distances are measured between rounded coordinates
+	rounded bool
+	// costs prices the solutions of the load set
+	costs CostModel
+	// constraints limit the shifts of the drivers
+	constraints Constraints
+	// service is the time spent at the docks of the loads that do not give their own
+	service Service
 }
 
 // NewLoadSet is a factory function for creating a new LoadSet.
 // Notice that the origin is added to each new LoadSet
 func NewLoadSet() *LoadSet {
-	loadset := &LoadSet{
-		LoadMap: make(map[int]*Load),
+	return &LoadSet{
+		LoadMap:     map[int]*Load{0: homeLoad},
+		ids:         make(map[string]*Load),
+		costs:       DefaultCostModel(),
+		constraints: DefaultConstraints(),
 	}
-	loadset.AddLoad(homeLoad)
-	return loadset
 }
 
 // Clone creates a new LoadSet that is identical
@@ -25,36 +40,112 @@ func (l *LoadSet) Clone() *LoadSet {
 	n := NewLoadSet()
 	n.size = l.size
 	for i := 1; i < n.size; i++ {
-		n.LoadMap[i] = l.LoadMap[i].clone()
+		load := l.LoadMap[i].clone()
+		n.LoadMap[i] = load
+		n.ids[load.ID] = load
 	}
 	n.Matrix = l.Matrix
+	n.rounded = l.rounded
+	n.costs = l.costs
+	n.constraints = l.constraints
+	n.service = l.service
 
 	return n
 }
 
-// AddLoad simply adds a load to the LoadMap
+// AddLoad adds a load to the LoadMap, numbering it after the loads
+// already added.  IDs are expected to be unique; see Load.
 func (l *LoadSet) AddLoad(load *Load) {
+	load.number = len(l.LoadMap)
 	l.LoadMap[load.number] = load
+	l.ids[load.ID] = load
+}
+
+// Load looks up a load by its ID
+func (l *LoadSet) Load(id string) (*Load, bool) {
+	load, ok := l.ids[id]
+	return load, ok
 }
 
 // FormDistanceMatrix is called once all Loads have been added to the LoadSet,
 // This method creates the matrix that calculates the distance
 // from Dropoff of the row number to the Pickup of the column number,
-// where the row and column numbers are the same as the load number
+// where the row and column numbers are the same as the load number.
+// Note that the diagonal holds the length of each load itself.
 func (l *LoadSet) FormDistanceMatrix() {
 	size := len(l.LoadMap)
 	l.size = size
-	matrix := make([][]uint64, size)
+	matrix := make([][]float64, size)
 	for k, v := range l.LoadMap {
-		row := make([]uint64, size)
+		row := make([]float64, size)
 		for i := 0; i < size; i++ {
-			row[i] = v.Dropoff.sqDistance(l.LoadMap[i].Pickup)
+			row[i] = v.Dropoff.distance(l.LoadMap[i].Pickup, l.rounded)
 		}
 		matrix[k] = row
 	}
 	l.Matrix = matrix
 }
 
+// RoundCoordinates switches the load set over to measuring distances between
+// coordinates rounded off to the nearest integer, rather than the exact ones,
+// and forms the distance matrix again
+func (l *LoadSet) RoundCoordinates() {
+	l.rounded = true
+	l.FormDistanceMatrix()
+}
+
+// Rounded reports whether distances are measured between rounded coordinates
+func (l *LoadSet) Rounded() bool {
+	return l.rounded
+}
+
+// SetCostModel changes how the solutions of the load set are priced
+func (l *LoadSet) SetCostModel(costs CostModel) {
+	l.costs = costs
+}
+
+// CostModel returns how the solutions of the load set are priced
+func (l *LoadSet) CostModel() CostModel {
+	return l.costs
+}
+
+// SetConstraints changes the limits on the shifts of the drivers
+func (l *LoadSet) SetConstraints(constraints Constraints) {
+	l.constraints = constraints
+}
+
+// Constraints returns the limits on the shifts of the drivers
+func (l *LoadSet) Constraints() Constraints {
+	return l.constraints
+}
+
+// SetDefaultService changes the time spent at the docks of
+// the loads that do not give their own service times
+func (l *LoadSet) SetDefaultService(service Service) {
+	l.service = service
+}
+
+// DefaultService returns the time spent at the docks of
+// the loads that do not give their own service times
+func (l *LoadSet) DefaultService() Service {
+	return l.service
+}
+
+// Unservable returns the loads that cannot be completed within the shift
+// limit and their windows, allowing for their service times, even by a driver
+// with no other loads, in the order they were added.
+// No solution exists unless this is empty.
+func (l *LoadSet) Unservable() []*Load {
+	loads := []*Load{}
+	for i := 1; i < len(l.LoadMap); i++ {
+		load := l.LoadMap[i]
+		if !l.withinShift(l.routeMinutes([]*Load{load})) {
+			loads = append(loads, load)
+		}
+	}
+	return loads
+}
+
 // IsFinished just checks to see if an uncompleted load still exists
 func (l *LoadSet) IsFinished() bool {
 	for _, v := range l.LoadMap {
@@ -0,0 +1,160 @@
+package models
+
+import "context"
+
+// maxSegment is the longest run of consecutive loads that is exchanged
+// between two drivers by a cross-exchange move
+const maxSegment = 3
+
+// Improve runs a local search over the routes of a complete solution.  Each
+// driver's route is reordered on its own (2-opt and Or-opt moves) and loads are
+// moved between drivers (relocate, swap, 2-opt* and cross-exchange moves) as long
+// as a move lowers the cost of the solution without any driver exceeding the
+// shift limit or missing a window.  Drivers left without loads are sent home for
+// good.  The search stops early, leaving a valid but less improved solution,
+// when ctx is done.
+func (s *DriverStable) Improve(ctx context.Context) {
+	moves := []func(a, b *Driver) bool{
+		s.relocate,
+		s.swap,
+		s.twoOptStar,
+		s.crossExchange,
+	}
+
+	for improved := true; improved && ctx.Err() == nil; {
+		improved = false
+		for _, d := range s.dispatchedDrivers {
+			if ctx.Err() != nil {
+				break
+			}
+			if d.optimizeRoute() {
+				improved = true
+			}
+		}
+		for _, move := range moves {
+			for _, a := range s.dispatchedDrivers {
+				for _, b := range s.dispatchedDrivers {
+					if ctx.Err() != nil {
+						break
+					}
+					// Keep applying the move to this pair of drivers
+					// until it no longer finds an improvement
+					for a != b && move(a, b) {
+						improved = true
+					}
+				}
+			}
+		}
+	}
+
+	s.dismissIdleDrivers()
+}
+
+// relocate moves a single load from the route of driver a to any position
+// in the route of driver b, applying the first move that lowers the cost
+func (s *DriverStable) relocate(a, b *Driver) bool {
+	aLoads, bLoads := a.completedLoads, b.completedLoads
+	before := a.shiftMinutes + b.shiftMinutes
+	drivers := occupied(len(aLoads)) + occupied(len(bLoads))
+
+	for i, load := range aLoads {
+		aDist := s.loadset.routeMinutes(aLoads[:i], aLoads[i+1:])
+		if !s.loadset.withinShift(aDist) {
+			continue
+		}
+		moved := []*Load{load}
+		for j := 0; j <= len(bLoads); j++ {
+			bDist := s.loadset.routeMinutes(bLoads[:j], moved, bLoads[j:])
+			if !s.loadset.withinShift(bDist) {
+				continue
+			}
+			delta := occupied(len(aLoads)-1) + 1 - drivers
+			if s.improves(before, aDist+bDist, delta) {
+				a.setRoute(join(aLoads[:i], aLoads[i+1:]))
+				b.setRoute(join(bLoads[:j], moved, bLoads[j:]))
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// swap exchanges a single load on the route of driver a with a single
+// load on the route of driver b
+func (s *DriverStable) swap(a, b *Driver) bool {
+	return s.exchange(a, b, 1, 1)
+}
+
+// crossExchange exchanges runs of up to maxSegment consecutive loads between
+// the routes of drivers a and b.  Exchanges of single loads are left to swap.
+func (s *DriverStable) crossExchange(a, b *Driver) bool {
+	return s.exchange(a, b, 2, maxSegment)
+}
+
+// exchange swaps a run of consecutive loads on the route of driver a with a run
+// on the route of driver b, where neither run is longer than maxLen and at least
+// one of them is as long as minLen.  The first exchange that lowers the cost is
+// applied.
+func (s *DriverStable) exchange(a, b *Driver, minLen, maxLen int) bool {
+	aLoads, bLoads := a.completedLoads, b.completedLoads
+	before := a.shiftMinutes + b.shiftMinutes
+
+	for i := range aLoads {
+		for k := 1; k <= maxLen && i+k <= len(aLoads); k++ {
+			for j := range bLoads {
+				for m := 1; m <= maxLen && j+m <= len(bLoads); m++ {
+					if k < minLen && m < minLen {
+						continue
+					}
+					aDist := s.loadset.routeMinutes(aLoads[:i], bLoads[j:j+m], aLoads[i+k:])
+					if !s.loadset.withinShift(aDist) {
+						continue
+					}
+					bDist := s.loadset.routeMinutes(bLoads[:j], aLoads[i:i+k], bLoads[j+m:])
+					if !s.loadset.withinShift(bDist) {
+						continue
+					}
+					if s.improves(before, aDist+bDist, 0) {
+						a.setRoute(join(aLoads[:i], bLoads[j:j+m], aLoads[i+k:]))
+						b.setRoute(join(bLoads[:j], aLoads[i:i+k], bLoads[j+m:]))
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// twoOptStar cuts the routes of drivers a and b in two and exchanges their
+// tails, so that each driver finishes the shift the other one started.  Moving
+// a whole route onto the end of another is included, which removes a driver.
+func (s *DriverStable) twoOptStar(a, b *Driver) bool {
+	aLoads, bLoads := a.completedLoads, b.completedLoads
+	before := a.shiftMinutes + b.shiftMinutes
+	drivers := occupied(len(aLoads)) + occupied(len(bLoads))
+
+	for i := 0; i <= len(aLoads); i++ {
+		for j := 0; j <= len(bLoads); j++ {
+			// Exchanging nothing, or everything, leaves the solution as it is
+			if (i == len(aLoads) && j == len(bLoads)) || (i == 0 && j == 0) {
+				continue
+			}
+			aDist := s.loadset.routeMinutes(aLoads[:i], bLoads[j:])
+			if !s.loadset.withinShift(aDist) {
+				continue
+			}
+			bDist := s.loadset.routeMinutes(bLoads[:j], aLoads[i:])
+			if !s.loadset.withinShift(bDist) {
+				continue
+			}
+			delta := occupied(i+len(bLoads)-j) + occupied(j+len(aLoads)-i) - drivers
+			if s.improves(before, aDist+bDist, delta) {
+				a.setRoute(join(aLoads[:i], bLoads[j:]))
+				b.setRoute(join(bLoads[:j], aLoads[i:]))
+				return true
+			}
+		}
+	}
+	return false
+}
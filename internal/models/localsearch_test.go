@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// newTestLoadSet builds a load set out of loads given as the coordinates
+// of their pickup and dropoff, numbering them from 1 in the order given
+func newTestLoadSet(coords ...[4]float64) *LoadSet {
+	loadset := NewLoadSet()
+	for i, c := range coords {
+		pickup := NewLocation(Pickup, c[0], c[1])
+		dropoff := NewLocation(Dropoff, c[2], c[3])
+		loadset.AddLoad(NewLoad(strconv.Itoa(i+1), pickup, dropoff, false))
+	}
+	loadset.FormDistanceMatrix()
+	return loadset
+}
+
+// newTestStable dispatches a driver for each of the routes, given as load numbers
+func newTestStable(loadset *LoadSet, routes ...[]int) *DriverStable {
+	stable := NewDriverStable(loadset, rand.New(rand.NewSource(1)))
+	for _, route := range routes {
+		loads := make([]*Load, len(route))
+		for i, n := range route {
+			loads[i] = loadset.LoadMap[n]
+			loads[i].complete = true
+		}
+		stable.DispatchNewDriver().setRoute(loads)
+	}
+	return stable
+}
+
+// twoClusters holds a run of three loads east of home, numbered 1 to 3,
+// and the same run west of home, numbered 4 to 6
+func twoClusters() *LoadSet {
+	return newTestLoadSet(
+		[4]float64{50, 0, 50, 5},
+		[4]float64{50, 5, 50, 10},
+		[4]float64{50, 10, 50, 15},
+		[4]float64{-50, 0, -50, 5},
+		[4]float64{-50, 5, -50, 10},
+		[4]float64{-50, 10, -50, 15},
+	)
+}
+
+func TestRelocateRemovesDriver(t *testing.T) {
+	// Three loads in a row along the x axis, the last of them on a driver of its own
+	loadset := newTestLoadSet(
+		[4]float64{10, 0, 20, 0},
+		[4]float64{20, 0, 30, 0},
+		[4]float64{30, 0, 40, 0},
+	)
+	// Only the route that takes the loads in order fits in the shift
+	loadset.SetConstraints(Constraints{MaxShiftMinutes: 90})
+	stable := newTestStable(loadset, []int{1, 2}, []int{3})
+	before := stable.CalculateCost()
+
+	if !stable.relocate(stable.dispatchedDrivers[1], stable.dispatchedDrivers[0]) {
+		t.Fatal("should have relocated the load")
+	}
+	if got := stable.dispatchedDrivers[0].completedLoadString(); got != "1,2,3" {
+		t.Fatalf("wrong route after relocation, got '%s'", got)
+	}
+	if len(stable.dispatchedDrivers[1].completedLoads) != 0 {
+		t.Fatal("the last driver should have no loads left")
+	}
+	// One driver less, and a route of 80 minutes instead of 60 and 80
+	if after := stable.CalculateCost(); after != before-560 {
+		t.Fatalf("wrong cost after relocation.  wanted=%v, got=%v", before-560, after)
+	}
+}
+
+func TestMovesLowerCost(t *testing.T) {
+	// Each pair of routes crosses between the clusters, which
+	// the move should at least partly undo
+	for _, tt := range []struct {
+		name   string
+		routes [][]int
+		move   func(s *DriverStable, a, b *Driver) bool
+	}{
+		{"swap", [][]int{{1, 2, 6}, {4, 5, 3}}, (*DriverStable).swap},
+		{"2-opt*", [][]int{{1, 2, 6}, {4, 5, 3}}, (*DriverStable).twoOptStar},
+		{"cross-exchange", [][]int{{1, 5, 6}, {4, 2, 3}}, (*DriverStable).crossExchange},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stable := newTestStable(twoClusters(), tt.routes...)
+			before := stable.CalculateCost()
+			if !tt.move(stable, stable.dispatchedDrivers[0], stable.dispatchedDrivers[1]) {
+				t.Fatal("should have found a move")
+			}
+			if after := stable.CalculateCost(); after >= before {
+				t.Fatalf("cost should have gone down.  before=%v, after=%v", before, after)
+			}
+		})
+	}
+}
+
+func TestImproveWithinShift(t *testing.T) {
+	stable := newTestStable(twoClusters(), []int{1}, []int{2}, []int{3}, []int{4}, []int{5}, []int{6})
+	// A driver can finish either cluster, but not both, within the shift
+	stable.loadset.SetConstraints(Constraints{MaxShiftMinutes: 150})
+	before := stable.CalculateCost()
+
+	stable.Improve(context.Background())
+
+	if after := stable.CalculateCost(); after >= before {
+		t.Fatalf("cost should have gone down.  before=%v, after=%v", before, after)
+	}
+	if len(stable.dispatchedDrivers) != 2 {
+		t.Fatalf("wrong number of drivers.  wanted=2, got=%d", len(stable.dispatchedDrivers))
+	}
+	for _, d := range stable.dispatchedDrivers {
+		if d.shiftMinutes > stable.ShiftLimit() {
+			t.Fatalf("route '%s' takes %v minutes, over the limit of %v", d.completedLoadString(), d.shiftMinutes, stable.ShiftLimit())
+		}
+	}
+	if total, unique := stable.Size(); total != 6 || unique != 6 {
+		t.Fatalf("wrong loads delivered.  total=%d, unique=%d", total, unique)
+	}
+}
@@ -6,19 +6,26 @@ import (
 	"strconv"
 )
 
-// Location is a struct that holds a type (pickup, dropoff, home) and
-// a cartesian coordinate that has been rounded off to the nearest integer
+// Location is a struct that holds a type (pickup, dropoff, home) and a
+// cartesian coordinate, both exactly as given and rounded off to the nearest
+// integer.  Distances are measured between the exact coordinates unless the
+// load set has been told to round them.
 type Location struct {
-	Type locationType
-	X    int32
-	Y    int32
+	Type   locationType
+	X      int32
+	Y      int32
+	ExactX float64
+	ExactY float64
 }
 
-func newLocation(t locationType, x int32, y int32) *Location {
+// NewLocation creates a location of the given type at the coordinates (x, y)
+func NewLocation(t locationType, x float64, y float64) *Location {
 	return &Location{
-		Type: t,
-		X:    x,
-		Y:    y,
+		Type:   t,
+		X:      int32(math.Round(x)),
+		Y:      int32(math.Round(y)),
+		ExactX: x,
+		ExactY: y,
 	}
 }
 
@@ -26,11 +33,11 @@ func newLocation(t locationType, x int32, y int32) *Location {
 // (-9.100071078494038,-48.89301103772511)
 func FormLocation(candidate []byte, t locationType) *Location {
 	cand := bytes.TrimSpace(candidate)
-	if cand[0] != lparen || cand[len(cand)-1] != rparen {
+	if len(cand) < 2 || cand[0] != lparen || cand[len(cand)-1] != rparen {
 		return nil
 	}
 
-	coords := bytes.Split(cand[1:len(cand)-2], comma)
+	coords := bytes.Split(cand[1:len(cand)-1], comma)
 	if len(coords) != 2 {
 		return nil
 	}
@@ -40,20 +47,20 @@ func FormLocation(candidate []byte, t locationType) *Location {
 		return nil
 	}
 
-	rx := int32(math.Round(x))
-
 	y, err := strconv.ParseFloat(string(coords[1]), 64)
 	if err != nil {
 		return nil
 	}
 
-	ry := int32(math.Round(y))
-
-	return newLocation(t, rx, ry)
+	return NewLocation(t, x, y)
 }
 
-func (l *Location) sqDistance(other *Location) uint64 {
-	delX := other.X - l.X
-	delY := other.Y - l.Y
-	return uint64(delX*delX + delY*delY)
+// distance is the straight line distance to the other location, which is
+// also the number of minutes it takes to drive there.  If rounded is set,
+// the distance is measured between the rounded coordinates instead.
+func (l *Location) distance(other *Location, rounded bool) float64 {
+	if rounded {
+		return math.Hypot(float64(other.X-l.X), float64(other.Y-l.Y))
+	}
+	return math.Hypot(other.ExactX-l.ExactX, other.ExactY-l.ExactY)
 }
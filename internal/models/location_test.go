@@ -0,0 +1,62 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormLocation(t *testing.T) {
+	l := FormLocation([]byte("(-9.100071078494038,-48.89301103772511)"), Pickup)
+	if l == nil {
+		t.Fatal("should have formed a location")
+	}
+	if l.ExactX != -9.100071078494038 || l.ExactY != -48.89301103772511 {
+		t.Fatalf("exact coordinates not preserved, got (%v,%v)", l.ExactX, l.ExactY)
+	}
+	if l.X != -9 || l.Y != -49 {
+		t.Fatalf("improper rounded coordinates, got (%d,%d)", l.X, l.Y)
+	}
+	if l.Type != Pickup {
+		t.Fatal("improper location type")
+	}
+}
+
+func TestFormLocationErrors(t *testing.T) {
+	for _, candidate := range []string{
+		"",
+		"(",
+		"-9.1,-48.9",
+		"(-9.1,-48.9",
+		"(-9.1)",
+		"(-9.1,-48.9,3)",
+		"(a,-48.9)",
+		"(-9.1,b)",
+	} {
+		if l := FormLocation([]byte(candidate), Dropoff); l != nil {
+			t.Fatalf("should not have formed a location from '%s'", candidate)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	a := NewLocation(Pickup, 0.4, 0.4)
+	b := NewLocation(Dropoff, 3.4, 4.4)
+
+	if d := a.distance(b, false); math.Abs(d-5) > 1e-9 {
+		t.Fatalf("wrong exact distance, got %v", d)
+	}
+
+	// Rounding moves b to (3,4) while a stays at the origin
+	if d := a.distance(b, true); math.Abs(d-5) > 1e-9 {
+		t.Fatalf("wrong rounded distance, got %v", d)
+	}
+
+	// Points within a unit of each other round to the same point
+	c := NewLocation(Pickup, 0.1, 0.2)
+	if d := a.distance(c, true); d != 0 {
+		t.Fatalf("rounded points should coincide, got %v", d)
+	}
+	if d := a.distance(c, false); d == 0 {
+		t.Fatal("exact points should not coincide")
+	}
+}
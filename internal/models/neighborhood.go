@@ -1,52 +1,78 @@
 package models
 
+import "container/heap"
+
+// neighborhood keeps the nearest of the loads inserted into it, up to its size.
+// It is a bounded heap with the farthest of the nearest loads at the top, so
+// that each insertion only has to compare against that one load.
 type neighborhood struct {
+	size      int
 	neighbors []*neighbor
+	inserted  int
 }
 
-func newNeighborhood() *neighborhood {
+func newNeighborhood(size int) *neighborhood {
 	return &neighborhood{
-		neighbors: make([]*neighbor, MaxNearestNeighbors),
+		size:      size,
+		neighbors: make([]*neighbor, 0, size),
 	}
 }
 
-// insert simply creates a nearest neighbor set as each
-// load is considered, rather than sorting a map later.
-// This is simply one solution to creating a set of nearest neighbors.
-func (n *neighborhood) insert(dist uint64, load *Load) {
-	for i := 0; i < MaxNearestNeighbors; i++ {
-		if n.neighbors[i] == nil {
-			n.neighbors[i] = &neighbor{
-				load: load,
-				dist: dist,
-			}
-			return
-		}
-		if dist <= n.neighbors[i].dist {
-			for j := MaxNearestNeighbors - 2; j >= i; j-- {
-				n.neighbors[j+1] = n.neighbors[j]
-			}
-			n.neighbors[i] = &neighbor{
-				load: load,
-				dist: dist,
-			}
-			return
-		}
+// insert considers a load at the given distance for the neighborhood.  Of
+// loads at the same distance, the one inserted last counts as the nearest.
+func (n *neighborhood) insert(dist float64, load *Load) {
+	n.inserted++
+	nb := &neighbor{load: load, dist: dist, order: n.inserted}
+	if len(n.neighbors) < n.size {
+		heap.Push(n, nb)
+		return
+	}
+	if n.size > 0 && dist <= n.neighbors[0].dist {
+		n.neighbors[0] = nb
+		heap.Fix(n, 0)
 	}
 }
 
-// prune just removes any neighbor slots that were not filled
-func (n *neighborhood) prune() {
-	neighbors := []*neighbor{}
-	for i := 0; i < MaxNearestNeighbors; i++ {
-		if n.neighbors[i] != nil {
-			neighbors = append(neighbors, n.neighbors[i])
-		}
+// sorted empties the neighborhood, returning the neighbors nearest first
+func (n *neighborhood) sorted() []*neighbor {
+	neighbors := make([]*neighbor, len(n.neighbors))
+	for i := len(neighbors) - 1; i >= 0; i-- {
+		neighbors[i] = heap.Pop(n).(*neighbor)
+	}
+	return neighbors
+}
+
+// Len, Less, Swap, Push and Pop implement heap.Interface, with the
+// farthest neighbor first
+func (n *neighborhood) Len() int {
+	return len(n.neighbors)
+}
+
+func (n *neighborhood) Less(i, j int) bool {
+	a, b := n.neighbors[i], n.neighbors[j]
+	if a.dist != b.dist {
+		return a.dist > b.dist
 	}
-	n.neighbors = neighbors
+	return a.order < b.order
+}
+
+func (n *neighborhood) Swap(i, j int) {
+	n.neighbors[i], n.neighbors[j] = n.neighbors[j], n.neighbors[i]
+}
+
+func (n *neighborhood) Push(x interface{}) {
+	n.neighbors = append(n.neighbors, x.(*neighbor))
+}
+
+func (n *neighborhood) Pop() interface{} {
+	last := n.neighbors[len(n.neighbors)-1]
+	n.neighbors = n.neighbors[:len(n.neighbors)-1]
+	return last
 }
 
 type neighbor struct {
 	load *Load
-	dist uint64
+	dist float64
+	// order counts the insertions into the neighborhood, to break ties in distance
+	order int
 }
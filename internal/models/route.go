@@ -0,0 +1,90 @@
+package models
+
+import "math"
+
+// routeMinutes measures the length of a shift that leaves home, completes
+// the loads of each segment in turn and returns home, adding up the length
+// of every leg along with the time spent loading and unloading and any time
+// spent waiting for a window to open.  A route that misses a window takes
+// forever, so that it never fits in a shift.  Taking the route in segments
+// allows candidate routes to be measured without having to build them first.
+func (l *LoadSet) routeMinutes(segments ...[]*Load) float64 {
+	var clock float64
+	prev := homeLoad
+	for _, segment := range segments {
+		for _, load := range segment {
+			var ok bool
+			if clock, ok = l.deliver(clock, prev, load); !ok {
+				return math.Inf(1)
+			}
+			prev = load
+		}
+	}
+	return clock + l.Matrix[prev.number][0]
+}
+
+// deliver returns the minute at which a driver who leaves the dropoff of prev
+// at the given minute has delivered and unloaded the load.  The driver waits
+// at the pickup or the dropoff if the window there has not opened yet, and
+// loading and unloading start once it has.  It reports false if the driver
+// misses either window.
+func (l *LoadSet) deliver(clock float64, prev *Load, load *Load) (float64, bool) {
+	clock, ok := load.PickupWindow.open(clock + l.Matrix[prev.number][load.number])
+	if !ok {
+		return clock, false
+	}
+	clock, ok = load.DropoffWindow.open(clock + l.loading(load) + l.Matrix[load.number][load.number])
+	return clock + l.unloading(load), ok
+}
+
+// RouteMinutes returns the length of the shift of a driver that leaves home,
+// completes the given loads in order and returns home, or +Inf if the driver
+// misses the window of one of the loads
+func (l *LoadSet) RouteMinutes(loads []*Load) float64 {
+	return l.routeMinutes(loads)
+}
+
+// insertionMinutes is the change in the length of a route when
+// the load is inserted in front of position j of the route
+func (l *LoadSet) insertionMinutes(loads []*Load, j int, load *Load) float64 {
+	return l.routeMinutes(loads[:j], []*Load{load}, loads[j:]) - l.routeMinutes(loads)
+}
+
+// removalMinutes is the change in the length of a
+// route when the load at position i is taken out
+func (l *LoadSet) removalMinutes(loads []*Load, i int) float64 {
+	return l.routeMinutes(loads[:i], loads[i+1:]) - l.routeMinutes(loads)
+}
+
+// withinShift checks that a shift of the given length can be completed
+// without exceeding the shift limit
+func (l *LoadSet) withinShift(minutes float64) bool {
+	return minutes <= l.constraints.MaxShiftMinutes
+}
+
+// ShiftLimit returns the longest shift, in minutes, that a driver may work
+func (l *LoadSet) ShiftLimit() float64 {
+	return l.constraints.MaxShiftMinutes
+}
+
+// join builds a single route out of the given segments
+func join(segments ...[]*Load) []*Load {
+	size := 0
+	for _, segment := range segments {
+		size += len(segment)
+	}
+	route := make([]*Load, 0, size)
+	for _, segment := range segments {
+		route = append(route, segment...)
+	}
+	return route
+}
+
+// occupied returns 1 if a route with the given number of loads needs a
+// driver, and 0 otherwise
+func occupied(loads int) int {
+	if loads > 0 {
+		return 1
+	}
+	return 0
+}
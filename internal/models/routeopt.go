@@ -0,0 +1,68 @@
+package models
+
+// maxOrOptSegment is the longest run of consecutive loads that
+// an Or-opt move shifts to another place in the same route
+const maxOrOptSegment = 3
+
+// optimizeRoute reorders the loads of a single driver that is back home,
+// using 2-opt and Or-opt moves until neither can shorten the shift.
+// It reports whether the route was changed.
+func (d *Driver) optimizeRoute() bool {
+	changed := false
+	for d.twoOpt() || d.orOpt() {
+		changed = true
+	}
+	return changed
+}
+
+// twoOpt reverses the order of a run of consecutive loads, applying the
+// first reversal that shortens the shift.  Unlike the classic 2-opt move
+// each load is still driven from its pickup to its dropoff, so only the
+// legs between loads change.
+func (d *Driver) twoOpt() bool {
+	loads := d.completedLoads
+	for i := 0; i < len(loads)-1; i++ {
+		for j := i + 2; j <= len(loads); j++ {
+			reversed := reverse(loads[i:j])
+			dist := d.network.routeMinutes(loads[:i], reversed, loads[j:])
+			if dist < d.shiftMinutes-minImprovement && d.network.withinShift(dist) {
+				d.setRoute(join(loads[:i], reversed, loads[j:]))
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orOpt moves a run of up to maxOrOptSegment consecutive loads to another
+// position in the route, applying the first move that shortens the shift
+func (d *Driver) orOpt() bool {
+	loads := d.completedLoads
+	for k := 1; k <= maxOrOptSegment && k < len(loads); k++ {
+		for i := 0; i+k <= len(loads); i++ {
+			segment := loads[i : i+k]
+			rest := join(loads[:i], loads[i+k:])
+			for j := 0; j <= len(rest); j++ {
+				// Putting the segment back where it came from changes nothing
+				if j == i {
+					continue
+				}
+				dist := d.network.routeMinutes(rest[:j], segment, rest[j:])
+				if dist < d.shiftMinutes-minImprovement && d.network.withinShift(dist) {
+					d.setRoute(join(rest[:j], segment, rest[j:]))
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// reverse returns a copy of the loads in the opposite order
+func reverse(loads []*Load) []*Load {
+	reversed := make([]*Load, len(loads))
+	for i, load := range loads {
+		reversed[len(loads)-1-i] = load
+	}
+	return reversed
+}
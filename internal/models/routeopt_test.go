@@ -0,0 +1,101 @@
+package models
+
+import "testing"
+
+// corners holds loads that are picked up and dropped off at the same place,
+// at the corners of a square: 1 at (10,0), 2 at (20,0), 3 at (20,10) and
+// 4 at (10,10)
+func corners() *LoadSet {
+	return newTestLoadSet(
+		[4]float64{10, 0, 10, 0},
+		[4]float64{20, 0, 20, 0},
+		[4]float64{20, 10, 20, 10},
+		[4]float64{10, 10, 10, 10},
+	)
+}
+
+func TestTwoOptUntangles(t *testing.T) {
+	// Driving 1,3,2,4 crosses the square along both of its diagonals
+	stable := newTestStable(corners(), []int{1, 3, 2, 4})
+	d := stable.dispatchedDrivers[0]
+	before := d.shiftMinutes
+
+	if !d.twoOpt() {
+		t.Fatal("should have untangled the route")
+	}
+	if got := d.completedLoadString(); got != "1,2,3,4" {
+		t.Fatalf("wrong route after 2-opt, got '%s'", got)
+	}
+	if d.shiftMinutes >= before {
+		t.Fatalf("route should be shorter.  before=%v, after=%v", before, d.shiftMinutes)
+	}
+}
+
+func TestOrOptMovesSegment(t *testing.T) {
+	// The loads of each set form a cycle, from which a driver can leave home
+	// at any load without driving empty until the way home.  Only the load
+	// numbered k+1 starts near home, so the route is shortest when the first
+	// k loads are moved to the end together.  Moving any one load on its own
+	// either starts the route just as far from home or breaks up the cycle.
+	for _, tt := range []struct {
+		name   string
+		points [][2]float64
+		want   string
+	}{
+		{"one load", [][2]float64{{0, 100}, {0, 10}}, "2,1"},
+		{"two loads", [][2]float64{{0, 100}, {100, 60}, {0, 10}, {-100, 60}}, "3,4,1,2"},
+		{"three loads", [][2]float64{{0, 100}, {100, 100}, {100, 40}, {0, 10}, {-100, 40}, {-100, 100}}, "4,5,6,1,2,3"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			coords := make([][4]float64, len(tt.points))
+			route := make([]int, len(tt.points))
+			for i, p := range tt.points {
+				next := tt.points[(i+1)%len(tt.points)]
+				coords[i] = [4]float64{p[0], p[1], next[0], next[1]}
+				route[i] = i + 1
+			}
+			stable := newTestStable(newTestLoadSet(coords...), route)
+			d := stable.dispatchedDrivers[0]
+			before := d.shiftMinutes
+
+			if !d.orOpt() {
+				t.Fatal("should have moved a segment")
+			}
+			if got := d.completedLoadString(); got != tt.want {
+				t.Fatalf("wrong route after Or-opt.  wanted='%s', got='%s'", tt.want, got)
+			}
+			if d.shiftMinutes >= before {
+				t.Fatalf("route should be shorter.  before=%v, after=%v", before, d.shiftMinutes)
+			}
+		})
+	}
+}
+
+func TestReorderWithinLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		setup func(l *LoadSet)
+	}{
+		{"shift limit", func(l *LoadSet) {
+			// The untangled route takes 54 minutes, still over the limit
+			l.SetConstraints(Constraints{MaxShiftMinutes: 50})
+		}},
+		{"window", func(l *LoadSet) {
+			// Untangling the route reaches load 3 too late, while the
+			// orders that reach it in time wait too long for load 4
+			l.LoadMap[3].PickupWindow = Window{Latest: 25, HasLatest: true}
+			l.LoadMap[4].PickupWindow = Window{Earliest: 40}
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			loadset := corners()
+			tt.setup(loadset)
+			stable := newTestStable(loadset, []int{1, 3, 2, 4})
+			d := stable.dispatchedDrivers[0]
+
+			if d.optimizeRoute() {
+				t.Fatalf("should not have reordered the route, got '%s'", d.completedLoadString())
+			}
+		})
+	}
+}
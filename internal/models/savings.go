@@ -0,0 +1,91 @@
+package models
+
+import "sort"
+
+// saving records how much shorter a schedule becomes when the driver that
+// delivers load from continues on to load to instead of going home in between
+type saving struct {
+	from  *Load
+	to    *Load
+	value float64
+}
+
+// savingsRoute is a route under construction by the savings heuristic
+type savingsRoute struct {
+	loads []*Load
+}
+
+// BuildSavings constructs a solution with the Clarke-Wright savings heuristic.
+// Every load starts out with a driver of its own, and routes are merged, in
+// decreasing order of the distance saved, by having the driver that finishes
+// one route go on to the first pickup of another, as long as the merged route
+// stays within the shift limit and meets the windows of its loads.  The stable
+// is expected to have no drivers yet.
+func (s *DriverStable) BuildSavings() {
+	network := s.loadset
+	size := network.size
+
+	// Give every load a route of its own, keeping track of which route each
+	// load is on
+	routes := make([]*savingsRoute, size)
+	for i := 1; i < size; i++ {
+		load := network.LoadMap[i]
+		routes[i] = &savingsRoute{loads: []*Load{load}}
+	}
+
+	// The saving of going from the dropoff of i straight to the pickup of j
+	// is the trip home from i plus the trip out to j, less the direct trip
+	savings := []saving{}
+	for i := 1; i < size; i++ {
+		for j := 1; j < size; j++ {
+			if i == j {
+				continue
+			}
+			value := network.Matrix[i][0] + network.Matrix[0][j] - network.Matrix[i][j]
+			if value > 0 {
+				savings = append(savings, saving{
+					from:  network.LoadMap[i],
+					to:    network.LoadMap[j],
+					value: value,
+				})
+			}
+		}
+	}
+	sort.SliceStable(savings, func(a, b int) bool {
+		return savings[a].value > savings[b].value
+	})
+
+	for _, sv := range savings {
+		from, to := routes[sv.from.number], routes[sv.to.number]
+		// Routes can only be merged when the first load ends one route
+		// and the second load starts a different one
+		if from == to || from.loads[len(from.loads)-1] != sv.from || to.loads[0] != sv.to {
+			continue
+		}
+
+		// The merged route is measured in full, as the later loads may be
+		// delivered at different times, or miss their windows altogether
+		if !s.loadset.withinShift(network.routeMinutes(from.loads, to.loads)) {
+			continue
+		}
+
+		from.loads = append(from.loads, to.loads...)
+		for _, load := range to.loads {
+			routes[load.number] = from
+		}
+	}
+
+	// Dispatch a driver for each of the remaining routes, in the order
+	// of the first load on the route
+	for i := 1; i < size; i++ {
+		route := routes[i]
+		if route.loads[0].number != i {
+			continue
+		}
+		driver := s.DispatchNewDriver()
+		driver.setRoute(route.loads)
+		for _, load := range route.loads {
+			load.complete = true
+		}
+	}
+}
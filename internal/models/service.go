@@ -0,0 +1,36 @@
+package models
+
+import "fmt"
+
+// Service is the time a driver spends at the docks of a load, on top of
+// the time spent driving
+type Service struct {
+	// Loading is the minutes spent at the pickup, once its window has opened
+	Loading float64
+	// Unloading is the minutes spent at the dropoff, once its window has opened
+	Unloading float64
+}
+
+// Validate checks that neither of the times is negative
+func (s Service) Validate() error {
+	if s.Loading < 0 || s.Unloading < 0 {
+		return fmt.Errorf("service times cannot be negative, got %v minutes loading and %v unloading", s.Loading, s.Unloading)
+	}
+	return nil
+}
+
+// loading returns the minutes spent loading the load at its pickup
+func (l *LoadSet) loading(load *Load) float64 {
+	if load.Loading != nil {
+		return *load.Loading
+	}
+	return l.service.Loading
+}
+
+// unloading returns the minutes spent unloading the load at its dropoff
+func (l *LoadSet) unloading(load *Load) float64 {
+	if load.Unloading != nil {
+		return *load.Unloading
+	}
+	return l.service.Unloading
+}
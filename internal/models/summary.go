@@ -0,0 +1,56 @@
+package models
+
+// RouteSummary breaks down the shift of a single driver
+type RouteSummary struct {
+	// Loads are the IDs of the loads, in the order they are completed
+	Loads []string
+	// Minutes is the length of the whole shift, including the drive home
+	Minutes float64
+	// LoadedMinutes is the time spent carrying loads from pickup to dropoff
+	LoadedMinutes float64
+	// DeadheadMinutes is the time spent driving empty, to a pickup or home
+	DeadheadMinutes float64
+	// WaitingMinutes is the time spent waiting for windows to open
+	WaitingMinutes float64
+	// ServiceMinutes is the time spent loading and unloading
+	ServiceMinutes float64
+	// SlackMinutes is how much longer the shift could be without exceeding the limit
+	SlackMinutes float64
+}
+
+// Summaries returns a summary of the route of each driver
+func (s *DriverStable) Summaries() []RouteSummary {
+	summaries := make([]RouteSummary, len(s.dispatchedDrivers))
+	for i, d := range s.dispatchedDrivers {
+		summaries[i] = s.loadset.summarize(d.completedLoads)
+	}
+	return summaries
+}
+
+// summarize breaks down the shift of a driver completing the given loads in order
+func (l *LoadSet) summarize(loads []*Load) RouteSummary {
+	summary := RouteSummary{Loads: make([]string, len(loads))}
+	for i, load := range loads {
+		summary.Loads[i] = load.ID
+		summary.LoadedMinutes += l.Matrix[load.number][load.number]
+	}
+	for _, leg := range l.Itinerary(loads, 0) {
+		switch leg.Type {
+		case Waiting:
+			summary.WaitingMinutes += leg.End - leg.Start
+		case Loading, Unloading:
+			summary.ServiceMinutes += leg.End - leg.Start
+		}
+	}
+	summary.Minutes = l.routeMinutes(loads)
+	summary.DeadheadMinutes = summary.Minutes - summary.LoadedMinutes - summary.WaitingMinutes - summary.ServiceMinutes
+	summary.SlackMinutes = l.constraints.MaxShiftMinutes - summary.Minutes
+	return summary
+}
+
+// CostBreakdown splits the cost of a solution into the fixed cost
+// of the drivers and the cost of the minutes they drive
+func (s *DriverStable) CostBreakdown() (driverCost float64, distanceCost float64) {
+	costs := s.loadset.costs
+	return costs.PerDriver * float64(s.activeDrivers()), costs.PerMinute * s.totalMinutes()
+}
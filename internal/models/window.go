@@ -0,0 +1,43 @@
+package models
+
+import "fmt"
+
+// Window is the span of time within which a load may be picked up or dropped
+// off, in minutes from the start of the shift.  A driver who arrives before
+// the window opens waits for it, while arriving after it closes is not allowed.
+// The zero Window allows any time at all.
+type Window struct {
+	// Earliest is the first minute at which the driver may be served
+	Earliest float64
+	// Latest is the last minute at which the driver may arrive, if HasLatest is set
+	Latest float64
+	// HasLatest is set when the window closes at Latest, rather than staying open
+	HasLatest bool
+}
+
+// Validate checks that the window does not close before it opens
+func (w Window) Validate() error {
+	if w.Earliest < 0 || w.Latest < 0 {
+		return fmt.Errorf("a window cannot start or end before the shift, got %v to %v", w.Earliest, w.Latest)
+	}
+	if w.HasLatest && w.Latest < w.Earliest {
+		return fmt.Errorf("the window closes at %v, before it opens at %v", w.Latest, w.Earliest)
+	}
+	return nil
+}
+
+// open returns the minute at which a driver arriving at the given minute can
+// be served, once the window has opened.  It reports false if the window has
+// already closed.
+func (w Window) open(arrival float64) (float64, bool) {
+	if arrival < w.Earliest {
+		return w.Earliest, true
+	}
+	return arrival, !w.HasLatest || arrival <= w.Latest
+}
+
+// Missed reports whether a driver arriving at the given minute is too late
+func (w Window) Missed(arrival float64) bool {
+	_, ok := w.open(arrival)
+	return !ok
+}
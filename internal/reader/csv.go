@@ -0,0 +1,238 @@
+package reader
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sched/internal/models"
+	"strconv"
+	"strings"
+)
+
+// The columns a CSV problem file must have, by the names they may go by.
+// Names are compared in lower case with underscores, dashes and spaces removed.
+var csvColumns = []struct {
+	name    string
+	aliases []string
+}{
+	{"loadNumber", []string{"loadnumber", "id", "load", "loadid"}},
+	{"pickupX", []string{"pickupx"}},
+	{"pickupY", []string{"pickupy"}},
+	{"dropoffX", []string{"dropoffx"}},
+	{"dropoffY", []string{"dropoffy"}},
+}
+
+// The columns a CSV problem file may have to give the windows of the loads,
+// in minutes from the start of the shift.  Empty fields leave that end of
+// the window open.
+var csvWindowColumns = []struct {
+	name    string
+	aliases []string
+}{
+	{"pickupEarliest", []string{"pickupearliest"}},
+	{"pickupLatest", []string{"pickuplatest"}},
+	{"dropoffEarliest", []string{"dropoffearliest"}},
+	{"dropoffLatest", []string{"dropofflatest"}},
+}
+
+// The columns a CSV problem file may have to give the minutes spent loading
+// and unloading each load.  Empty fields take the default of the LoadSet.
+var csvServiceColumns = []struct {
+	name    string
+	aliases []string
+}{
+	{"loading", []string{"loading", "loadingminutes"}},
+	{"unloading", []string{"unloading", "unloadingminutes"}},
+}
+
+// readCSV reads a problem file of comma-separated values with a header row
+func readCSV(r io.Reader, b *builder) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return csvError(err)
+	}
+
+	// Find the index of each column in the header
+	indexes := make([]int, len(csvColumns))
+	for i, column := range csvColumns {
+		indexes[i] = csvColumn(header, column.aliases)
+		if indexes[i] < 0 {
+			return &ParseError{
+				Line:   1,
+				Column: 1,
+				Text:   strings.Join(header, ","),
+				Reason: "the header has no " + column.name + " column",
+			}
+		}
+	}
+	windowIndexes := make([]int, len(csvWindowColumns))
+	for i, column := range csvWindowColumns {
+		windowIndexes[i] = csvColumn(header, column.aliases)
+	}
+	serviceIndexes := make([]int, len(csvServiceColumns))
+	for i, column := range csvServiceColumns {
+		serviceIndexes[i] = csvColumn(header, column.aliases)
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return csvError(err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		load, perr := csvLoad(cr, record, indexes, windowIndexes, serviceIndexes)
+		if perr != nil {
+			perr.Line = line
+			if err := b.fail(perr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, column := cr.FieldPos(indexes[0])
+		if err := b.add(line, column, load); err != nil {
+			return err
+		}
+	}
+}
+
+// csvLoad extracts the load number, the pickup and dropoff locations and any
+// windows and service times from a single record, as a Load.  If there is a
+// problem, it is described by the returned ParseError, which is left to the
+// caller to fill in with the line number.
+func csvLoad(cr *csv.Reader, record []string, indexes []int, windowIndexes []int, serviceIndexes []int) (*models.Load, *ParseError) {
+	for i, index := range indexes {
+		if index >= len(record) {
+			_, column := cr.FieldPos(len(record) - 1)
+			return nil, &ParseError{
+				Column: column,
+				Text:   strings.Join(record, ","),
+				Reason: "the record has no " + csvColumns[i].name + " field",
+			}
+		}
+	}
+
+	id := strings.TrimSpace(record[indexes[0]])
+	if id == "" {
+		_, column := cr.FieldPos(indexes[0])
+		return nil, &ParseError{
+			Column: column,
+			Text:   strings.Join(record, ","),
+			Reason: "the load number is missing",
+		}
+	}
+
+	coords := make([]float64, 4)
+	for i := range coords {
+		index := indexes[i+1]
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[index]), 64)
+		if err != nil {
+			_, column := cr.FieldPos(index)
+			return nil, &ParseError{
+				Column: column,
+				Text:   record[index],
+				Reason: "the " + csvColumns[i+1].name + " coordinate is not a number",
+			}
+		}
+		coords[i] = v
+	}
+
+	// Window columns that are missing from the header, or from the end of
+	// the record, leave the window open
+	times := make([]float64, len(windowIndexes))
+	given := make([]bool, len(windowIndexes))
+	for i, index := range windowIndexes {
+		if index < 0 || index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[index]), 64)
+		if err != nil {
+			_, column := cr.FieldPos(index)
+			return nil, &ParseError{
+				Column: column,
+				Text:   record[index],
+				Reason: "the " + csvWindowColumns[i].name + " time is not a number of minutes",
+			}
+		}
+		times[i], given[i] = v, true
+	}
+
+	load := models.NewLoad(id,
+		models.NewLocation(models.Pickup, coords[0], coords[1]),
+		models.NewLocation(models.Dropoff, coords[2], coords[3]),
+		false)
+	windows := []*models.Window{&load.PickupWindow, &load.DropoffWindow}
+	for i, window := range windows {
+		var reason string
+		*window, reason = checkWindow(models.Window{Earliest: times[2*i], Latest: times[2*i+1], HasLatest: given[2*i+1]})
+		if reason != "" {
+			_, column := cr.FieldPos(indexes[0])
+			return nil, &ParseError{
+				Column: column,
+				Text:   strings.Join(record, ","),
+				Reason: "the " + windowNames[i] + " window " + reason,
+			}
+		}
+	}
+
+	services := []**float64{&load.Loading, &load.Unloading}
+	for i, index := range serviceIndexes {
+		if index < 0 || index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			continue
+		}
+		reason := "is not a number of minutes"
+		v, err := strconv.ParseFloat(strings.TrimSpace(record[index]), 64)
+		if err == nil {
+			*services[i], reason = checkService(v)
+		}
+		if reason != "" {
+			_, column := cr.FieldPos(index)
+			return nil, &ParseError{
+				Column: column,
+				Text:   record[index],
+				Reason: "the " + csvServiceColumns[i].name + " time " + reason,
+			}
+		}
+	}
+	return load, nil
+}
+
+// csvColumn returns the index of the header column going by any of the
+// given names, or -1 if there is none
+func csvColumn(header []string, aliases []string) int {
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer("_", "", "-", "", " ", "").Replace(name)
+		for _, alias := range aliases {
+			if name == alias {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// csvError turns an error from the CSV reader into a *ParseError, if it
+// is about the contents of the file
+func csvError(err error) error {
+	var cerr *csv.ParseError
+	if !errors.As(err, &cerr) {
+		return err
+	}
+	return &ParseError{
+		Line:   cerr.Line,
+		Column: cerr.Column,
+		Reason: cerr.Err.Error(),
+	}
+}
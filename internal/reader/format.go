@@ -0,0 +1,94 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the layout of a problem file
+type Format int
+
+const (
+	// FormatAuto picks the format from the extension of the file name
+	// (.txt, .csv or .json) or, failing that, from the first line of the file
+	FormatAuto Format = iota
+	// FormatText is the space-separated "loadNumber pickup dropoff" format,
+	// with each location written as (x,y), optionally followed by the pickup
+	// and dropoff windows written as [earliest,latest] and the minutes spent
+	// loading and unloading
+	FormatText
+	// FormatCSV is comma-separated values with a header row naming the
+	// columns loadNumber, pickupX, pickupY, dropoffX and dropoffY, in any
+	// order.  The load number column may also be called id or load, case and
+	// underscores are ignored.  The windows of the loads may be given in
+	// pickupEarliest, pickupLatest, dropoffEarliest and dropoffLatest columns,
+	// where empty fields leave the window open, and the minutes spent loading
+	// and unloading in loading and unloading columns, where empty fields take
+	// the default.  Any other columns are skipped.
+	//
+	// loadNumber,pickupX,pickupY,dropoffX,dropoffY
+	// 1,-9.100071078494038,-48.89301103772511,-116.78442279683607,76.80147820713637
+	FormatCSV
+	// FormatJSON is a JSON array of loads, or an object holding the array
+	// under "loads".  Each load has an id (a string or a number) and a pickup
+	// and a dropoff, each with an x and a y.  A pickupWindow and a dropoffWindow,
+	// each with an earliest and a latest minute, are optional, as are the
+	// loadingMinutes and unloadingMinutes.  Any other fields are skipped.
+	//
+	// {"loads": [
+	//   {"id": "1", "pickup": {"x": -9.1, "y": -48.9}, "dropoff": {"x": -116.8, "y": 76.8},
+	//    "pickupWindow": {"earliest": 60, "latest": 120}}
+	// ]}
+	FormatJSON
+)
+
+var formatNames = map[Format]string{
+	FormatAuto: "auto",
+	FormatText: "text",
+	FormatCSV:  "csv",
+	FormatJSON: "json",
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// ParseFormat returns the format with the given name (auto, text, csv or json)
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if strings.EqualFold(name, n) {
+			return f, nil
+		}
+	}
+	return FormatAuto, fmt.Errorf("unknown format '%s', expected auto, text, csv or json", name)
+}
+
+// detectFormat guesses the format of a problem file from its name or, if the
+// name does not settle it, from the first line of the file
+func detectFormat(filename string, r *bufio.Reader) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt":
+		return FormatText
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	}
+
+	// Peek at as much as is buffered, which is all that is needed to see the first line
+	head, _ := r.Peek(r.Size())
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if len(head) > 0 && (head[0] == '[' || head[0] == '{') {
+		return FormatJSON
+	}
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+	if !bytes.ContainsRune(head, '(') && bytes.ContainsRune(head, ',') {
+		return FormatCSV
+	}
+	return FormatText
+}
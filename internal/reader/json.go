@@ -0,0 +1,217 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sched/internal/models"
+)
+
+// jsonLoad is a single load of a JSON problem file
+type jsonLoad struct {
+	ID         json.RawMessage `json:"id"`
+	LoadNumber json.RawMessage `json:"loadNumber"`
+	Pickup     *jsonPoint      `json:"pickup"`
+	Dropoff    *jsonPoint      `json:"dropoff"`
+	// The windows are optional, as are either of their ends
+	PickupWindow  jsonWindow `json:"pickupWindow"`
+	DropoffWindow jsonWindow `json:"dropoffWindow"`
+	// Service times that are missing take the default of the LoadSet
+	Loading   *float64 `json:"loadingMinutes"`
+	Unloading *float64 `json:"unloadingMinutes"`
+}
+
+// jsonPoint is a location of a JSON problem file
+type jsonPoint struct {
+	X *float64 `json:"x"`
+	Y *float64 `json:"y"`
+}
+
+// jsonWindow is a window of a JSON problem file, in minutes from the start of
+// the shift.  A window without a latest minute never closes.
+type jsonWindow struct {
+	Earliest float64  `json:"earliest"`
+	Latest   *float64 `json:"latest"`
+}
+
+// readJSON reads a problem file holding a JSON array of loads, or an object
+// with the array under "loads"
+func readJSON(r io.Reader, b *builder) error {
+	// The whole document is kept so that offsets can be turned into lines and columns
+	doc, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	if err := jsonLoads(dec, doc); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		line, column := position(doc, dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return jsonError(err, doc, line, column)
+		}
+
+		load, perr := processJSON(raw)
+		if perr != nil {
+			perr.Line, perr.Column = line, column
+			if err := b.fail(perr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := b.add(line, column, load); err != nil {
+			return err
+		}
+	}
+
+	// Check that the array is closed
+	if _, err := dec.Token(); err != nil {
+		line, column := position(doc, dec.InputOffset())
+		return jsonError(err, doc, line, column)
+	}
+	return nil
+}
+
+// jsonLoads moves the decoder onto the first load of the array of loads,
+// skipping over any other fields of an enclosing object
+func jsonLoads(dec *json.Decoder, doc []byte) error {
+	fail := func(reason string) error {
+		line, column := position(doc, dec.InputOffset())
+		return &ParseError{Line: line, Column: column, Reason: reason}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonError(err, doc, 1, 1)
+	}
+	if tok == json.Delim('[') {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fail("expected an array of loads, or an object with the array under \"loads\"")
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return jsonError(err, doc, 1, 1)
+		}
+		if key == "loads" {
+			tok, err := dec.Token()
+			if err != nil {
+				return jsonError(err, doc, 1, 1)
+			}
+			if tok != json.Delim('[') {
+				return fail("expected the loads to be an array")
+			}
+			return nil
+		}
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return jsonError(err, doc, 1, 1)
+		}
+	}
+	return fail("the object has no \"loads\" array")
+}
+
+// processJSON extracts the load number, the pickup and dropoff locations and
+// any windows and service times from a single load, as a Load.  If there is a
+// problem, it is described by the returned ParseError, which is left to the
+// caller to fill in with the line and column.
+func processJSON(raw json.RawMessage) (*models.Load, *ParseError) {
+	var load jsonLoad
+	if err := json.Unmarshal(raw, &load); err != nil {
+		reason := "the load is not an object with an id, a pickup and a dropoff"
+		var terr *json.UnmarshalTypeError
+		if errors.As(err, &terr) && terr.Field != "" {
+			reason = "the " + terr.Field + " field should not be a " + terr.Value
+		}
+		return nil, &ParseError{Text: string(raw), Reason: reason}
+	}
+
+	number := load.ID
+	if number == nil {
+		number = load.LoadNumber
+	}
+	var id, s string
+	switch {
+	case json.Unmarshal(number, &s) == nil && s != "":
+		id = s
+	case len(number) > 0 && (number[0] == '-' || (number[0] >= '0' && number[0] <= '9')):
+		id = string(number)
+	default:
+		return nil, &ParseError{Text: string(raw), Reason: "the load has no id that is a string or a number"}
+	}
+
+	if load.Pickup == nil || load.Pickup.X == nil || load.Pickup.Y == nil {
+		return nil, &ParseError{Text: string(raw), Reason: "the load has no pickup with an x and a y"}
+	}
+	if load.Dropoff == nil || load.Dropoff.X == nil || load.Dropoff.Y == nil {
+		return nil, &ParseError{Text: string(raw), Reason: "the load has no dropoff with an x and a y"}
+	}
+
+	l := models.NewLoad(id,
+		models.NewLocation(models.Pickup, *load.Pickup.X, *load.Pickup.Y),
+		models.NewLocation(models.Dropoff, *load.Dropoff.X, *load.Dropoff.Y),
+		false)
+	windows := []*models.Window{&l.PickupWindow, &l.DropoffWindow}
+	for i, w := range []jsonWindow{load.PickupWindow, load.DropoffWindow} {
+		window := models.Window{Earliest: w.Earliest}
+		if w.Latest != nil {
+			window.Latest, window.HasLatest = *w.Latest, true
+		}
+		var reason string
+		*windows[i], reason = checkWindow(window)
+		if reason != "" {
+			return nil, &ParseError{Text: string(raw), Reason: "the " + windowNames[i] + " window " + reason}
+		}
+	}
+	services := []**float64{&l.Loading, &l.Unloading}
+	for i, minutes := range []*float64{load.Loading, load.Unloading} {
+		if minutes == nil {
+			continue
+		}
+		var reason string
+		if *services[i], reason = checkService(*minutes); reason != "" {
+			return nil, &ParseError{Text: string(raw), Reason: "the " + serviceNames[i] + " time " + reason}
+		}
+	}
+	return l, nil
+}
+
+// jsonError turns an error from the JSON decoder into a *ParseError, placed
+// where the decoder found it if it says so, or else at the given line and column
+func jsonError(err error, doc []byte, line, column int) error {
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		line, column = position(doc, serr.Offset)
+	} else if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	return &ParseError{Line: line, Column: column, Reason: "the JSON is malformed: " + err.Error()}
+}
+
+// position returns the line and column, counting from 1, of the first byte
+// of doc at or after offset that is not white space or a comma
+func position(doc []byte, offset int64) (line, column int) {
+	i := int(offset)
+	for i < len(doc) && bytes.IndexByte([]byte(" \t\r\n,:"), doc[i]) >= 0 {
+		i++
+	}
+	line = 1 + bytes.Count(doc[:i], []byte("\n"))
+	column = i + 1
+	if last := bytes.LastIndexByte(doc[:i], '\n'); last >= 0 {
+		column = i - last
+	}
+	return line, column
+}
@@ -5,89 +5,339 @@
 // 2 (73.38933871575719,-86.93443314676254) (-57.594533352956425,28.662926099543245)\
 // ...
 //
+// A load may also be given a window for its pickup and another for its dropoff,
+// each written as [earliest,latest] in minutes from the start of the shift.
+// Either end may be left empty, and - stands for no window at all.  The
+// windows may be followed by the minutes spent loading and unloading the load,
+// where - stands for the default of the LoadSet:
+//
+// loadNumber pickup dropoff pickupWindow dropoffWindow loading unloading
+// 1 (-9.1,-48.9) (-116.8,76.8) [60,120] - 30 45
+// 2 (73.4,-86.9) (-57.6,28.7) - [,480]
+//
+// The same loads may also be given as CSV or JSON; see Format.
+//
 // # Returning a LoadSet struct
 //
-// CAVEAT: The problem file is assumed to label the points consecutively starting at 1.
-// If this is not the case, pre-processing of the file is needed.
+// A load number may be any text without spaces, such as 17 or TMS-300, and
+// the numbers need not be consecutive, but each must appear only once.
+//
+// Lines that cannot be read are reported as a *ParseError, giving the line
+// and column at which the problem was found.
 package reader
 
 import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"sched/internal/models"
 	"strconv"
+	"strings"
 )
 
+// ParseError describes a line of a problem file that could not be read
+type ParseError struct {
+	// Line is the line number, counting from 1
+	Line int
+	// Column is the byte offset within the line, counting from 1,
+	// at which the offending text starts
+	Column int
+	// Text is the offending text
+	Text string
+	// Reason explains what was expected instead
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s: '%s'", e.Line, e.Column, e.Reason, e.Text)
+}
+
+// ParseErrors lists every line of a problem file that could not be read
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
 // CreateLoadSet reads a problem file and returns a LoadSet struct
 // consisting of all the loads defined in the file, along with a load
-// representing the origin, labeled as the 0 load.
-func CreateLoadSet(filename string) *models.LoadSet {
+// representing the origin, labeled as the 0 load.  The format of the file
+// is detected as described for FormatAuto.  Reading stops at the first
+// line that cannot be read, which is returned as a *ParseError.
+func CreateLoadSet(filename string) (*models.LoadSet, error) {
+	return CreateLoadSetFormat(filename, FormatAuto, false)
+}
+
+// CreateLoadSetAll is like CreateLoadSet, but carries on past lines that
+// cannot be read so that all of them are reported at once, as ParseErrors
+func CreateLoadSetAll(filename string) (*models.LoadSet, error) {
+	return CreateLoadSetFormat(filename, FormatAuto, true)
+}
+
+// CreateLoadSetFormat reads a problem file in the given format.  If collect
+// is set, every line that cannot be read is reported, as for CreateLoadSetAll.
+func CreateLoadSetFormat(filename string, format Format, collect bool) (*models.LoadSet, error) {
 	f, err := os.OpenFile(filename, os.O_RDONLY, os.ModePerm)
 	if err != nil {
-		println(err.Error())
-		return nil
+		return nil, err
 	}
 	defer f.Close()
 
-	// Create the loadset to return
-	loadset := models.NewLoadSet()
+	return ReadLoadSet(f, filename, format, collect)
+}
+
+// ReadLoadSet reads a problem from r, such as standard input, in the given
+// format.  The name, if there is one, is only used to detect the format from
+// its extension.  If collect is set, every line that cannot be read is
+// reported, as for CreateLoadSetAll.
+func ReadLoadSet(rd io.Reader, name string, format Format, collect bool) (*models.LoadSet, error) {
+	r := bufio.NewReader(rd)
+	if format == FormatAuto {
+		format = detectFormat(name, r)
+	}
+
+	var err error
+	b := newBuilder(collect)
+	switch format {
+	case FormatCSV:
+		err = readCSV(r, b)
+	case FormatJSON:
+		err = readJSON(r, b)
+	default:
+		err = readText(r, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.result()
+}
+
+// builder collects the loads read from a problem file into a LoadSet,
+// along with the lines that could not be read
+type builder struct {
+	loadset *models.LoadSet
+	errs    ParseErrors
+	collect bool
+}
+
+func newBuilder(collect bool) *builder {
+	return &builder{
+		loadset: models.NewLoadSet(),
+		errs:    ParseErrors{},
+		collect: collect,
+	}
+}
+
+// fail records a line that could not be read.  It returns the error to stop
+// reading with, or nil if reading should carry on to find further errors.
+func (b *builder) fail(perr *ParseError) error {
+	if !b.collect {
+		return perr
+	}
+	b.errs = append(b.errs, perr)
+	return nil
+}
+
+// add adds a load to the LoadSet, unless its load number has already been used
+// or could not be read back from a printed solution, where load IDs are
+// separated by commas within brackets and lines starting with '#' are skipped
+func (b *builder) add(line, column int, load *models.Load) error {
+	if strings.ContainsAny(load.ID, ",[]") || strings.HasPrefix(load.ID, "#") {
+		return b.fail(&ParseError{
+			Line:   line,
+			Column: column,
+			Text:   load.ID,
+			Reason: "a load ID may not contain ',', '[' or ']', nor start with '#'",
+		})
+	}
+	if _, ok := b.loadset.Load(load.ID); ok {
+		return b.fail(&ParseError{
+			Line:   line,
+			Column: column,
+			Text:   load.ID,
+			Reason: "the load number has already been used",
+		})
+	}
+	b.loadset.AddLoad(load)
+	return nil
+}
+
+// result returns the finished LoadSet, or the errors found along the way
+func (b *builder) result() (*models.LoadSet, error) {
+	if len(b.errs) > 0 {
+		return nil, b.errs
+	}
+	b.loadset.FormDistanceMatrix()
+	return b.loadset, nil
+}
 
-	// Process the file line-by-line
-	sc := bufio.NewScanner(f)
-	for sc.Scan() {
-		val := sc.Bytes()
-		loadNumber, pickup, dropoff := processLine(val)
-		if loadNumber == -1 {
+// readText reads a problem file in the space-separated text format
+func readText(r io.Reader, b *builder) error {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		load, perr := processLine(sc.Bytes())
+		if perr != nil {
+			perr.Line = line
+			if err := b.fail(perr); err != nil {
+				return err
+			}
 			continue
 		}
-		if pickup == nil {
-			return nil
+		if load == nil {
+			continue
 		}
 
-		load := models.NewLoad(loadNumber, pickup, dropoff, false)
-		loadset.AddLoad(load)
+		if err := b.add(line, 1, load); err != nil {
+			return err
+		}
 	}
-
-	loadset.FormDistanceMatrix()
-
-	return loadset
+	return sc.Err()
 }
 
-// For each line, extract the load number and the pickup and dropoff locations.  If there is a
-// problem, print the problem and return nil locations so that the reader can stop.
-func processLine(val []byte) (loadNumber int, pickup *models.Location, dropoff *models.Location) {
+// For each line, extract the load number, the pickup and dropoff locations and
+// any windows and service times, as a Load.  A nil Load marks the header line.
+// If there is a problem, it is described by the returned ParseError, which is
+// left to the caller to fill in with the line number.
+func processLine(val []byte) (*models.Load, *ParseError) {
 	vals := bytes.Split(val, []byte(" "))
 
-	if len(vals) != 3 {
-		_, _ = fmt.Printf("Line '%s' did not have three fields.  Skipping", val)
-		return 0, nil, nil
+	if len(vals) < 3 || len(vals) > 7 {
+		return nil, &ParseError{
+			Column: 1,
+			Text:   string(val),
+			Reason: "expected three to seven fields separated by spaces (loadNumber pickup dropoff [pickupWindow [dropoffWindow [loading [unloading]]]])",
+		}
+	}
+
+	// The column at which each field starts
+	columns := make([]int, len(vals))
+	columns[0] = 1
+	for i := 1; i < len(vals); i++ {
+		columns[i] = columns[i-1] + len(vals[i-1]) + 1
 	}
 
-	ln := string(vals[0])
-	loadNum, err := strconv.ParseInt(ln, 10, 0)
-	if err != nil {
-		if string(ln) != "loadNumber" {
-			_, _ = fmt.Printf("Line '%s' did not have an integer to start it.  Skipping", val)
-			return 0, nil, nil
+	loadNumber := string(vals[0])
+	if loadNumber == "" {
+		return nil, &ParseError{
+			Column: columns[0],
+			Text:   string(val),
+			Reason: "the load number is missing",
 		}
-		return -1, nil, nil
+	}
+	if loadNumber == "loadNumber" {
+		return nil, nil
 	}
 
-	loadNumber = int(loadNum)
-
-	pickup = models.FormLocation(vals[1], models.Pickup)
+	pickup := models.FormLocation(vals[1], models.Pickup)
 	if pickup == nil {
-		_, _ = fmt.Printf("Line '%s' did not have a location as its second element.  Skipping", val)
-		return 0, nil, nil
+		return nil, &ParseError{
+			Column: columns[1],
+			Text:   string(vals[1]),
+			Reason: "the pickup is not a location of the form (x,y)",
+		}
 	}
 
-	dropoff = models.FormLocation(vals[2], models.Dropoff)
+	dropoff := models.FormLocation(vals[2], models.Dropoff)
 	if dropoff == nil {
-		_, _ = fmt.Printf("Line '%s' did not have a location as its third element.  Skipping", val)
-		return 0, nil, nil
+		return nil, &ParseError{
+			Column: columns[2],
+			Text:   string(vals[2]),
+			Reason: "the dropoff is not a location of the form (x,y)",
+		}
+	}
+
+	load := models.NewLoad(loadNumber, pickup, dropoff, false)
+	windows := []*models.Window{&load.PickupWindow, &load.DropoffWindow}
+	services := []**float64{&load.Loading, &load.Unloading}
+	for i, field := range vals[3:] {
+		var what, reason string
+		if i < len(windows) {
+			what = windowNames[i] + " window"
+			*windows[i], reason = parseWindow(string(field))
+		} else {
+			what = serviceNames[i-len(windows)] + " time"
+			*services[i-len(windows)], reason = parseService(string(field))
+		}
+		if reason != "" {
+			return nil, &ParseError{
+				Column: columns[3+i],
+				Text:   string(field),
+				Reason: "the " + what + " " + reason,
+			}
+		}
+	}
+
+	return load, nil
+}
+
+// windowNames names the windows of a load, in the order they are given
+var windowNames = [2]string{"pickup", "dropoff"}
+
+// parseWindow reads a window of the form [earliest,latest], where either end
+// may be left empty, or - for no window.  If the window cannot be read, the
+// reason is returned, worded to follow the name of the window.
+func parseWindow(text string) (models.Window, string) {
+	if text == "-" {
+		return models.Window{}, ""
+	}
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return models.Window{}, "is not of the form [earliest,latest]"
+	}
+	ends := strings.Split(text[1:len(text)-1], ",")
+	if len(ends) != 2 {
+		return models.Window{}, "is not of the form [earliest,latest]"
+	}
+	var values [2]float64
+	for i, end := range ends {
+		if end == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(end, 64)
+		if err != nil {
+			return models.Window{}, "has an end that is not a number of minutes"
+		}
+		values[i] = v
 	}
+	return checkWindow(models.Window{Earliest: values[0], Latest: values[1], HasLatest: ends[1] != ""})
+}
 
-	return loadNumber, pickup, dropoff
+// checkWindow returns the window, along with the reason it is not valid, if
+// it is not, worded to follow the name of the window
+func checkWindow(window models.Window) (models.Window, string) {
+	if err := window.Validate(); err != nil {
+		return models.Window{}, "is not valid: " + err.Error()
+	}
+	return window, ""
+}
+
+// serviceNames names the service times of a load, in the order they are given
+var serviceNames = [2]string{"loading", "unloading"}
+
+// parseService reads a number of minutes spent loading or unloading, or - for
+// the default of the LoadSet, which is returned as nil.  If the time cannot be
+// read, the reason is returned, worded to follow the name of the time.
+func parseService(text string) (*float64, string) {
+	if text == "-" {
+		return nil, ""
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, "is not a number of minutes"
+	}
+	return checkService(v)
+}
+
+// checkService returns the service time, along with the reason it is not valid,
+// if it is not, worded to follow the name of the time
+func checkService(minutes float64) (*float64, string) {
+	if minutes < 0 {
+		return nil, "cannot be negative"
+	}
+	return &minutes, ""
 }
@@ -1,18 +1,23 @@
 package reader
 
-import "testing"
+import (
+	"errors"
+	"sched/internal/models"
+	"strings"
+	"testing"
+)
 
 func TestBadInput(t *testing.T) {
-	loadset := CreateLoadSet("non-existent-file")
-	if loadset != nil {
+	loadset, err := CreateLoadSet("non-existent-file")
+	if loadset != nil || err == nil {
 		t.Fatal("failed to properly respond to non-existent file")
 	}
 }
 
 func TestEmptySet(t *testing.T) {
-	loadset := CreateLoadSet("./testfiles/empty.txt")
-	if loadset == nil {
-		t.Fatal("should have read existent, empty file")
+	loadset, err := CreateLoadSet("./testfiles/empty.txt")
+	if err != nil {
+		t.Fatalf("should have read existent, empty file: %s", err)
 	}
 	if len(loadset.LoadMap) != 1 {
 		t.Fatal("should have gotten a LoadMap with just the origin in the loadset")
@@ -27,9 +32,9 @@ func TestEmptySet(t *testing.T) {
 }
 
 func TestSmallSet(t *testing.T) {
-	loadset := CreateLoadSet("./testfiles/single.txt")
-	if loadset == nil {
-		t.Fatal("should have read existent file")
+	loadset, err := CreateLoadSet("./testfiles/single.txt")
+	if err != nil {
+		t.Fatalf("should have read existent file: %s", err)
 	}
 	if len(loadset.LoadMap) != 2 {
 		t.Fatal("should have gotten a LoadMap with the origin and one other point in the loadset")
@@ -48,25 +53,305 @@ func TestSmallSet(t *testing.T) {
 	if o.Dropoff.X != -117 || o.Dropoff.Y != 77 || o.Pickup.X != -9 || o.Pickup.Y != -49 {
 		t.Fatal("improper read of point in loadset")
 	}
+	if o.Pickup.ExactX != -9.100071078494038 || o.Pickup.ExactY != -48.89301103772511 ||
+		o.Dropoff.ExactX != -116.78442279683607 || o.Dropoff.ExactY != 76.80147820713637 {
+		t.Fatal("exact coordinates of point in loadset not preserved")
+	}
 }
 
 func TestNoLoadNumberError(t *testing.T) {
-	loadset := CreateLoadSet("./testfiles/no_load_number.txt")
+	loadset, err := CreateLoadSet("./testfiles/no_load_number.txt")
 	if loadset != nil {
 		t.Fatal("failed to properly read file containing a missing load number")
 	}
+	checkParseError(t, err, 4, 1, " (-109.23071648186891,-94.63347501104835) (134.9870047348522,-41.02728921942559)")
+}
+
+func TestStringLoadNumbers(t *testing.T) {
+	loadset, err := CreateLoadSet("./testfiles/string_ids.txt")
+	if err != nil {
+		t.Fatalf("should have read file with string load numbers: %s", err)
+	}
+	for i, id := range []string{"17", "TMS-300", "5"} {
+		load, ok := loadset.Load(id)
+		if !ok {
+			t.Fatalf("should have found load %s", id)
+		}
+		if loadset.LoadMap[i+1] != load {
+			t.Fatalf("load %s should be numbered %d in file order", id, i+1)
+		}
+	}
+}
+
+func TestUnprintableLoadIDs(t *testing.T) {
+	// Load IDs that would not read back from a printed solution
+	for _, id := range []string{"A,B", "[x]", "x]", "#1"} {
+		for _, tt := range []struct {
+			format  Format
+			problem string
+		}{
+			{FormatText, "loadNumber pickup dropoff\n" + id + " (0,0) (0,10)\n"},
+			{FormatCSV, "id,pickupX,pickupY,dropoffX,dropoffY\n\"" + id + "\",0,0,0,10\n"},
+			{FormatJSON, `[{"id": "` + id + `", "pickup": {"x": 0, "y": 0}, "dropoff": {"x": 0, "y": 10}}]`},
+		} {
+			_, err := ReadLoadSet(strings.NewReader(tt.problem), "", tt.format, false)
+			var perr *ParseError
+			if !errors.As(err, &perr) || perr.Text != id {
+				t.Fatalf("should not have read load ID '%s' from the %s problem, got %v", id, tt.format, err)
+			}
+		}
+	}
+
+	// A '#' is fine after the start of an ID
+	if _, err := ReadLoadSet(strings.NewReader("loadNumber pickup dropoff\nA#1 (0,0) (0,10)\n"), "", FormatText, false); err != nil {
+		t.Fatalf("should have read load ID 'A#1': %s", err)
+	}
 }
 
 func TestBadLocationError(t *testing.T) {
-	loadset := CreateLoadSet("./testfiles/bad_location.txt")
+	loadset, err := CreateLoadSet("./testfiles/bad_location.txt")
 	if loadset != nil {
 		t.Fatal("failed to properly read a file with a bad location")
 	}
+	checkParseError(t, err, 4, 3, "-109.23071648186891,-94.63347501104835")
 }
 
 func TestMissingLocationError(t *testing.T) {
-	loadset := CreateLoadSet("./testfiles/missing_location.txt")
+	loadset, err := CreateLoadSet("./testfiles/missing_location.txt")
 	if loadset != nil {
 		t.Fatal("failed to properly read a file with a missing location")
 	}
+	checkParseError(t, err, 4, 1, "3 (134.9870047348522,-41.02728921942559)")
+}
+
+func TestAllErrors(t *testing.T) {
+	loadset, err := CreateLoadSetAll("./testfiles/many_errors.txt")
+	if loadset != nil {
+		t.Fatal("failed to properly read a file with several errors")
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ParseErrors, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %s", len(errs), err)
+	}
+	lines := []int{3, 4, 6}
+	for i, e := range errs {
+		if e.Line != lines[i] {
+			t.Fatalf("expected error %d on line %d, got line %d", i, lines[i], e.Line)
+		}
+	}
+	checkParseError(t, errs[0], 3, 1, "1")
+}
+
+// checkParseError checks that err is a ParseError found at the given place
+func checkParseError(t *testing.T, err error, line, column int, text string) {
+	t.Helper()
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	if perr.Line != line || perr.Column != column || perr.Text != text {
+		t.Fatalf("expected error at line %d, column %d with text '%s', got %s", line, column, text, perr)
+	}
+}
+
+func TestReadSolution(t *testing.T) {
+	routes, err := ReadSolution("./testfiles/solution.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || len(routes[0]) != 2 || routes[0][1] != "2" || len(routes[1]) != 1 || routes[1][0] != "3" {
+		t.Fatalf("improper read of solution, got %v", routes)
+	}
+}
+
+func TestBadSolution(t *testing.T) {
+	if _, err := ReadSolution("./testfiles/bad_solution.txt"); err == nil {
+		t.Fatal("failed to reject a route without brackets")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, filename := range []string{
+		"./testfiles/loads.csv",
+		"./testfiles/loads.json",
+		// Without an extension the format is told from the contents
+		"./testfiles/loads_csv",
+		"./testfiles/loads_json",
+	} {
+		loadset, err := CreateLoadSet(filename)
+		if err != nil {
+			t.Fatalf("should have read %s: %s", filename, err)
+		}
+		if loadset.Size() != 2 {
+			t.Fatalf("should have read 2 loads from %s, got %d", filename, loadset.Size())
+		}
+		load, ok := loadset.Load("TMS-300")
+		if !ok || loadset.LoadMap[2] != load {
+			t.Fatalf("should have read load TMS-300 second from %s", filename)
+		}
+		if _, ok := loadset.Load("17"); !ok {
+			t.Fatalf("should have read load 17 from %s", filename)
+		}
+		if load.Dropoff.ExactX != -57.594533352956425 || load.Dropoff.ExactY != 28.662926099543245 {
+			t.Fatalf("improper read of load TMS-300 from %s", filename)
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	for _, filename := range []string{
+		"./testfiles/windows.txt",
+		"./testfiles/windows.csv",
+		"./testfiles/windows.json",
+	} {
+		loadset, err := CreateLoadSet(filename)
+		if err != nil {
+			t.Fatalf("should have read %s: %s", filename, err)
+		}
+		want := map[string][2]models.Window{
+			"1": {{Earliest: 60, Latest: 120, HasLatest: true}, {}},
+			"2": {{}, {Latest: 480, HasLatest: true}},
+			"3": {{}, {}},
+			"4": {{Earliest: 30}, {}},
+		}
+		for id, windows := range want {
+			load, ok := loadset.Load(id)
+			if !ok {
+				t.Fatalf("should have read load %s from %s", id, filename)
+			}
+			if load.PickupWindow != windows[0] || load.DropoffWindow != windows[1] {
+				t.Fatalf("improper windows of load %s from %s, got %v and %v", id, filename, load.PickupWindow, load.DropoffWindow)
+			}
+		}
+	}
+
+	_, err := CreateLoadSet("./testfiles/bad_window.txt")
+	checkParseError(t, err, 3, 19, "[90,60]")
+}
+
+func TestWindowsClosingAtZero(t *testing.T) {
+	// A window may close at minute 0, but not before it opens
+	for _, tt := range []struct {
+		format     Format
+		open, late string
+	}{
+		{FormatText,
+			"loadNumber pickup dropoff pickupWindow\n1 (0,0) (0,10) [,0]\n",
+			"loadNumber pickup dropoff pickupWindow\n1 (0,0) (0,10) [30,0]\n"},
+		{FormatCSV,
+			"id,pickupX,pickupY,dropoffX,dropoffY,pickupLatest\n1,0,0,0,10,0\n",
+			"id,pickupX,pickupY,dropoffX,dropoffY,pickupEarliest,pickupLatest\n1,0,0,0,10,30,0\n"},
+		{FormatJSON,
+			`[{"id": 1, "pickup": {"x": 0, "y": 0}, "dropoff": {"x": 0, "y": 10}, "pickupWindow": {"latest": 0}}]`,
+			`[{"id": 1, "pickup": {"x": 0, "y": 0}, "dropoff": {"x": 0, "y": 10}, "pickupWindow": {"earliest": 30, "latest": 0}}]`},
+	} {
+		loadset, err := ReadLoadSet(strings.NewReader(tt.open), "", tt.format, false)
+		if err != nil {
+			t.Fatalf("should have read the %s problem: %s", tt.format, err)
+		}
+		load, _ := loadset.Load("1")
+		if want := (models.Window{Latest: 0, HasLatest: true}); load.PickupWindow != want {
+			t.Fatalf("improper pickup window from the %s problem, got %+v", tt.format, load.PickupWindow)
+		}
+
+		if _, err := ReadLoadSet(strings.NewReader(tt.late), "", tt.format, false); err == nil {
+			t.Fatalf("should not have read a window that closes before it opens from the %s problem", tt.format)
+		}
+	}
+}
+
+func TestServiceTimes(t *testing.T) {
+	for _, filename := range []string{
+		"./testfiles/service.txt",
+		"./testfiles/service.csv",
+		"./testfiles/service.json",
+	} {
+		loadset, err := CreateLoadSet(filename)
+		if err != nil {
+			t.Fatalf("should have read %s: %s", filename, err)
+		}
+		// Negative times stand for the default, which is left as nil
+		want := map[string][2]float64{
+			"1": {30, 45},
+			"2": {20, -1},
+			"3": {-1, -1},
+			"4": {-1, 15},
+		}
+		for id, times := range want {
+			load, ok := loadset.Load(id)
+			if !ok {
+				t.Fatalf("should have read load %s from %s", id, filename)
+			}
+			for i, got := range []*float64{load.Loading, load.Unloading} {
+				if (got == nil) != (times[i] < 0) || (got != nil && *got != times[i]) {
+					t.Fatalf("improper service times of load %s from %s, got %v and %v", id, filename, load.Loading, load.Unloading)
+				}
+			}
+		}
+		if load, _ := loadset.Load("2"); load.PickupWindow.Earliest != 60 {
+			t.Fatalf("improper pickup window of load 2 from %s, got %v", filename, load.PickupWindow)
+		}
+	}
+
+	_, err := CreateLoadSet("./testfiles/bad_service.csv")
+	checkParseError(t, err, 3, 13, "-5")
+}
+
+func TestCSVErrors(t *testing.T) {
+	_, err := CreateLoadSet("./testfiles/bad_loads.csv")
+	checkParseError(t, err, 3, 8, "north")
+
+	_, err = CreateLoadSetAll("./testfiles/bad_loads.csv")
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", err)
+	}
+	checkParseError(t, errs[1], 4, 1, "1")
+	checkParseError(t, errs[2], 5, 8, "3,73.4,-86.9")
+}
+
+func TestJSONErrors(t *testing.T) {
+	_, err := CreateLoadSetAll("./testfiles/bad_loads.json")
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	if errs[0].Line != 3 || errs[0].Column != 3 || errs[1].Line != 4 || errs[1].Column != 3 {
+		t.Fatalf("errors found in the wrong places: %s", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{FormatAuto, FormatText, FormatCSV, FormatJSON} {
+		if parsed, err := ParseFormat(f.String()); err != nil || parsed != f {
+			t.Fatalf("format %s did not parse back, got %v, %v", f, parsed, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatal("should have rejected an unknown format")
+	}
+}
+
+func TestReadLoadSet(t *testing.T) {
+	problem := "id,pickupX,pickupY,dropoffX,dropoffY\n1,0,10,0,20\n2,0,30,0,40\n"
+	loadset, err := ReadLoadSet(strings.NewReader(problem), "", FormatAuto, false)
+	if err != nil {
+		t.Fatalf("should have read the problem: %s", err)
+	}
+	if loadset.Size() != 2 {
+		t.Fatalf("should have read 2 loads, got %d", loadset.Size())
+	}
+}
+
+func TestReadSolutionFrom(t *testing.T) {
+	routes, err := ReadSolutionFrom(strings.NewReader("# problem.txt\n[1,2]\n\n[3]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || len(routes[0]) != 2 || routes[1][0] != "3" {
+		t.Fatalf("improper read of labeled solution, got %v", routes)
+	}
 }
@@ -0,0 +1,62 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadSolution reads a solution file in the form printed by the scheduler,
+//
+// [1,6,5]
+// [2,3]
+// [4]
+//
+// and returns the load IDs of each driver's route, in order.
+// Blank lines and lines starting with #, such as the labels printed
+// when several problems are solved at once, are ignored.
+func ReadSolution(filename string) ([][]string, error) {
+	f, err := os.OpenFile(filename, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSolutionFrom(f)
+}
+
+// ReadSolutionFrom reads a solution from r, such as standard input, as for ReadSolution
+func ReadSolutionFrom(r io.Reader) ([][]string, error) {
+	routes := [][]string{}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		val := bytes.TrimSpace(sc.Bytes())
+		if len(val) == 0 || val[0] == '#' {
+			continue
+		}
+
+		if val[0] != '[' || val[len(val)-1] != ']' {
+			return nil, fmt.Errorf("line %d: '%s' is not a route in square brackets", line, val)
+		}
+
+		route := []string{}
+		inner := bytes.TrimSpace(val[1 : len(val)-1])
+		if len(inner) > 0 {
+			for _, field := range bytes.Split(inner, []byte(",")) {
+				loadNumber := string(bytes.TrimSpace(field))
+				if loadNumber == "" {
+					return nil, fmt.Errorf("line %d: a load number is missing", line)
+				}
+				route = append(route, loadNumber)
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"sched/internal/models"
+)
+
+// reportTemplate lays out the report as a single page needing nothing but a browser
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.summary td { padding: 0.1em 1em 0.1em 0; }
+.chart { margin-top: 2em; }
+.row { display: flex; align-items: center; height: 1.6em; }
+.label { width: 7em; font-size: 0.85em; }
+.shift { position: relative; flex: 1; height: 1.1em; background: #f2f2f2; }
+.bar { position: absolute; top: 0; height: 100%; }
+.loaded { background: #2e7d32; }
+.deadhead { background: #ef9a9a; }
+.waiting { background: #ffe082; }
+.service { background: #90caf9; }
+.utilization { width: 9em; text-align: right; font-size: 0.85em; }
+.axis { position: relative; flex: 1; height: 1.4em; font-size: 0.75em; }
+.tick { position: absolute; transform: translateX(-50%); }
+.legend span { display: inline-block; width: 1em; height: 1em; margin: 0 0.3em 0 1em; vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table class="summary">
+<tr><td>Drivers</td><td>{{.Drivers}}</td></tr>
+<tr><td>Total minutes</td><td>{{printf "%.2f" .TotalMinutes}}</td></tr>
+<tr><td>Driver cost</td><td>{{printf "%.2f" .DriverCost}}</td></tr>
+<tr><td>Distance cost</td><td>{{printf "%.2f" .DistanceCost}}</td></tr>
+<tr><td>Total cost</td><td>{{printf "%.2f" .Cost}}</td></tr>
+<tr><td>Average utilization</td><td>{{printf "%.1f" .Utilization}}%</td></tr>
+</table>
+<p class="legend"><span class="loaded"></span>loaded<span class="deadhead"></span>deadhead<span class="waiting"></span>waiting<span class="service"></span>loading and unloading</p>
+<div class="chart">
+{{range .Rows}}<div class="row">
+<div class="label">Driver {{.Driver}}</div>
+<div class="shift">{{range .Bars}}<div class="bar {{.Class}}" style="left: {{printf "%.3f" .Left}}%; width: {{printf "%.3f" .Width}}%" title="{{.Title}}"></div>{{end}}</div>
+<div class="utilization">{{printf "%.1f" .Utilization}}% ({{printf "%.1f" .Loaded}}% loaded)</div>
+</div>
+{{end}}<div class="row">
+<div class="label">Minutes</div>
+<div class="axis">{{range .Ticks}}<span class="tick" style="left: {{printf "%.3f" .Left}}%">{{.Minute}}</span>{{end}}</div>
+<div class="utilization"></div>
+</div>
+</div>
+</body>
+</html>
+`))
+
+// reportData is everything shown on the report
+type reportData struct {
+	Title        string
+	Drivers      int
+	TotalMinutes float64
+	DriverCost   float64
+	DistanceCost float64
+	Cost         float64
+	Utilization  float64
+	Rows         []reportRow
+	Ticks        []reportTick
+}
+
+// reportRow is the shift of a single driver
+type reportRow struct {
+	Driver int
+	Bars   []reportBar
+	// Utilization and Loaded are the percentages of the shift limit
+	// spent working at all and carrying loads
+	Utilization float64
+	Loaded      float64
+}
+
+// reportBar is a single leg of a shift, placed as a percentage of the shift limit
+type reportBar struct {
+	Class string
+	Left  float64
+	Width float64
+	Title string
+}
+
+// reportTick marks the minutes along the bottom of the chart
+type reportTick struct {
+	Minute int
+	Left   float64
+}
+
+// Report writes a self-contained HTML page summarizing the cost of a solution
+// and drawing each driver's shift as a row of a Gantt chart, with the legs
+// carrying a load, the legs driven empty (deadhead), any waiting for windows
+// to open and the time spent loading and unloading in different colors, along
+// an axis running from the start of the shift to the shift limit.
+func Report(w io.Writer, title string, stable *models.DriverStable) error {
+	limit := stable.ShiftLimit()
+	data := reportData{Title: title, Cost: stable.CalculateCost()}
+	data.DriverCost, data.DistanceCost = stable.CostBreakdown()
+
+	summaries := stable.Summaries()
+	for i, legs := range stable.Itineraries(0) {
+		row := reportRow{
+			Driver:      i + 1,
+			Utilization: 100 * summaries[i].Minutes / limit,
+			Loaded:      100 * summaries[i].LoadedMinutes / limit,
+		}
+		for _, leg := range legs {
+			bar := reportBar{
+				Class: "deadhead",
+				Left:  100 * leg.Start / limit,
+				Width: 100 * (leg.End - leg.Start) / limit,
+			}
+			switch leg.Type {
+			case models.Carrying:
+				bar.Class, bar.Title = "loaded", "carrying load "+leg.Load
+			case models.ToPickup:
+				bar.Title = "to the pickup of load " + leg.Load
+			case models.ToHome:
+				bar.Title = "driving home"
+			case models.Waiting:
+				bar.Class, bar.Title = "waiting", "waiting for the window of load "+leg.Load
+			case models.Loading:
+				bar.Class, bar.Title = "service", "loading load "+leg.Load
+			case models.Unloading:
+				bar.Class, bar.Title = "service", "unloading load "+leg.Load
+			}
+			row.Bars = append(row.Bars, bar)
+		}
+		data.Rows = append(data.Rows, row)
+		data.TotalMinutes += summaries[i].Minutes
+	}
+	data.Drivers = len(data.Rows)
+	if data.Drivers > 0 {
+		data.Utilization = 100 * data.TotalMinutes / (limit * float64(data.Drivers))
+	}
+
+	for m := 0; float64(m) <= limit; m += 60 {
+		data.Ticks = append(data.Ticks, reportTick{Minute: m, Left: 100 * float64(m) / limit})
+	}
+
+	return reportTemplate.Execute(w, data)
+}
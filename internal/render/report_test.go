@@ -0,0 +1,32 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	stable := solve(t)
+
+	var buf bytes.Buffer
+	if err := Report(&buf, "Test <problem>", stable); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+
+	if !strings.Contains(report, "<title>Test &lt;problem&gt;</title>") {
+		t.Fatal("the title should be escaped")
+	}
+	drivers := len(stable.Solution())
+	if n := strings.Count(report, `<div class="label">Driver `); n != drivers {
+		t.Fatalf("expected a row for each of %d drivers, got %d", drivers, n)
+	}
+	if n := strings.Count(report, `class="bar loaded"`); n != 200 {
+		t.Fatalf("expected a loaded bar for each of 200 loads, got %d", n)
+	}
+	if !strings.Contains(report, fmt.Sprintf("%.2f", stable.CalculateCost())) {
+		t.Fatal("the report should give the cost of the solution")
+	}
+}
@@ -0,0 +1,93 @@
+// Package render draws solutions for people to look at: a map of the
+// routes as an SVG image, and a report of the drivers' shifts
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sched/internal/models"
+)
+
+const (
+	// mapSize is the width or height, in pixels, of the larger side of the map
+	mapSize = 1000
+	// mapMargin is the space left around the locations on the map
+	mapMargin = 20
+)
+
+// SVG draws a map of the routes of a solution as a standalone SVG image.
+// The depot is a black square, pickups are filled circles and dropoffs are
+// open circles.  Every driver's route is drawn in a color of its own, with
+// the legs carrying a load solid and the legs driven empty dashed.
+func SVG(w io.Writer, stable *models.DriverStable) error {
+	itineraries := stable.Itineraries(0)
+
+	// Fit every location, along with the depot at the origin, onto the map
+	var minX, maxX, minY, maxY float64
+	for _, legs := range itineraries {
+		for _, leg := range legs {
+			for _, l := range []*models.Location{leg.From, leg.To} {
+				minX, maxX = math.Min(minX, l.ExactX), math.Max(maxX, l.ExactX)
+				minY, maxY = math.Min(minY, l.ExactY), math.Max(maxY, l.ExactY)
+			}
+		}
+	}
+	scale := (mapSize - 2*mapMargin) / math.Max(math.Max(maxX-minX, maxY-minY), 1)
+	width := 2*mapMargin + (maxX-minX)*scale
+	height := 2*mapMargin + (maxY-minY)*scale
+	// The y axis of the image points down, so it is flipped
+	point := func(l *models.Location) (float64, float64) {
+		return mapMargin + (l.ExactX-minX)*scale, mapMargin + (maxY-l.ExactY)*scale
+	}
+
+	b := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`+"\n",
+		width, height, width, height)
+	_, _ = fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	for i, legs := range itineraries {
+		color := routeColor(i)
+		_, _ = fmt.Fprintf(b, `<g stroke="%s" fill="%s" stroke-width="1.5">`+"\n", color, color)
+		_, _ = fmt.Fprintf(b, "<title>driver %d</title>\n", i+1)
+		for _, leg := range legs {
+			// Waiting, loading and unloading go nowhere
+			if leg.Type == models.Waiting || leg.Type == models.Loading || leg.Type == models.Unloading {
+				continue
+			}
+			x1, y1 := point(leg.From)
+			x2, y2 := point(leg.To)
+			dash := ""
+			if leg.Type != models.Carrying {
+				dash = ` stroke-dasharray="6,4"`
+			}
+			_, _ = fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"%s/>`+"\n", x1, y1, x2, y2, dash)
+		}
+		for _, leg := range legs {
+			x, y := point(leg.To)
+			switch leg.Type {
+			case models.ToPickup:
+				_, _ = fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="4"><title>pickup of load %s</title></circle>`+"\n",
+					x, y, html.EscapeString(leg.Load))
+			case models.Carrying:
+				_, _ = fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="4" fill="white"><title>dropoff of load %s</title></circle>`+"\n",
+					x, y, html.EscapeString(leg.Load))
+			}
+		}
+		_, _ = fmt.Fprintln(b, "</g>")
+	}
+
+	x, y := point(models.NewLocation(models.Home, 0, 0))
+	_, _ = fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="black"><title>depot</title></rect>`+"\n", x-5, y-5)
+	_, _ = fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// routeColor picks the color of the route of the given driver, stepping
+// round the color wheel by the golden angle so that neighboring drivers
+// get colors that are far apart
+func routeColor(driver int) string {
+	return fmt.Sprintf("hsl(%.0f,70%%,45%%)", math.Mod(float64(driver)*137.508, 360))
+}
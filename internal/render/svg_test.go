@@ -0,0 +1,59 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"sched/internal/models"
+	"sched/internal/reader"
+	"sched/internal/solver"
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	stable := solve(t)
+
+	var buf bytes.Buffer
+	if err := SVG(&buf, stable); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+
+	// The image is well formed, with a group for each driver
+	// and a solid line for each load
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("malformed SVG: %s", err)
+			}
+			break
+		}
+	}
+	if n := strings.Count(svg, "<g "); n != len(stable.Solution()) {
+		t.Fatalf("expected a group for each of %d drivers, got %d", len(stable.Solution()), n)
+	}
+	if n := strings.Count(svg, "<line ") - strings.Count(svg, "stroke-dasharray"); n != 200 {
+		t.Fatalf("expected a solid line for each of 200 loads, got %d", n)
+	}
+}
+
+// solve finds a solution to the test problem
+func solve(t *testing.T) *models.DriverStable {
+	t.Helper()
+	loadset, err := reader.CreateLoadSet("../solver/testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategies, err := solver.LookupAll([]string{"savings"}, solver.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := solver.Solve(context.Background(), loadset, solver.Options{Strategies: strategies, Seed: 1})
+	if result.Stable == nil {
+		t.Fatal("no solution found")
+	}
+	return result.Stable
+}
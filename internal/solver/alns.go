@@ -0,0 +1,51 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sched/internal/models"
+)
+
+func init() {
+	Register("alns", func(p Params) (Strategy, error) {
+		if err := p.Walk.Validate(); err != nil {
+			return nil, err
+		}
+		if p.ALNS.Iterations == 0 && p.ALNS.Budget == 0 {
+			return nil, fmt.Errorf("the alns strategy needs an iteration limit or a time budget")
+		}
+		return &AdaptiveSearch{Walk: p.Walk, Params: p.ALNS}, nil
+	})
+}
+
+// AdaptiveSearch starts from the best of the nearest neighbor walks and improves
+// it by adaptive large neighborhood search, finishing off with the same local
+// search used by the other strategies.  The registered strategy takes its walks
+// and parameters from the Params it is looked up with, which trade running time
+// against solution quality.
+type AdaptiveSearch struct {
+	Walk   WalkParams
+	Params models.ALNSParams
+}
+
+// Name identifies the strategy in the registry
+func (*AdaptiveSearch) Name() string {
+	return "alns"
+}
+
+// Starts returns the starts of the nearest neighbor walks
+func (a *AdaptiveSearch) Starts() (int, int) {
+	return a.Walk.Deterministic, a.Walk.Randomized
+}
+
+// Construct builds the starting solution using a nearest neighbor walk
+func (a *AdaptiveSearch) Construct(loadset *models.LoadSet, start int, rng *rand.Rand) *models.DriverStable {
+	return (&NearestNeighbor{Walk: a.Walk}).Construct(loadset, start, rng)
+}
+
+// Improve runs the search and then a local search on the best solution found
+func (a *AdaptiveSearch) Improve(ctx context.Context, stable *models.DriverStable) {
+	stable.ALNS(ctx, a.Params)
+	stable.Improve(ctx)
+}
@@ -0,0 +1,51 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sched/internal/models"
+)
+
+func init() {
+	Register("anneal", func(p Params) (Strategy, error) {
+		if err := p.Walk.Validate(); err != nil {
+			return nil, err
+		}
+		if p.Anneal.Iterations == 0 && p.Anneal.Budget == 0 {
+			return nil, fmt.Errorf("the anneal strategy needs an iteration limit or a time budget")
+		}
+		return &Annealing{Walk: p.Walk, Schedule: p.Anneal}, nil
+	})
+}
+
+// Annealing starts from the best of the nearest neighbor walks and improves it
+// by simulated annealing, finishing off with the same local search used by the
+// other strategies.  The registered strategy takes its walks and schedule from
+// the Params it is looked up with, which trade running time against solution
+// quality.
+type Annealing struct {
+	Walk     WalkParams
+	Schedule models.AnnealSchedule
+}
+
+// Name identifies the strategy in the registry
+func (*Annealing) Name() string {
+	return "anneal"
+}
+
+// Starts returns the starts of the nearest neighbor walks
+func (a *Annealing) Starts() (int, int) {
+	return a.Walk.Deterministic, a.Walk.Randomized
+}
+
+// Construct builds the starting solution using a nearest neighbor walk
+func (a *Annealing) Construct(loadset *models.LoadSet, start int, rng *rand.Rand) *models.DriverStable {
+	return (&NearestNeighbor{Walk: a.Walk}).Construct(loadset, start, rng)
+}
+
+// Improve anneals the solution and then runs a local search on the best solution seen
+func (a *Annealing) Improve(ctx context.Context, stable *models.DriverStable) {
+	stable.Anneal(ctx, a.Schedule)
+	stable.Improve(ctx)
+}
@@ -0,0 +1,111 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sched/internal/models"
+)
+
+func init() {
+	Register("nearest", func(p Params) (Strategy, error) {
+		if err := p.Walk.Validate(); err != nil {
+			return nil, err
+		}
+		return &NearestNeighbor{Walk: p.Walk}, nil
+	})
+}
+
+// WalkParams sets up the nearest neighbor walks that build the starting
+// solutions of the nearest, anneal and alns strategies
+type WalkParams struct {
+	// Neighbors is the number of nearest pickups that a driver chooses between
+	Neighbors int
+	// Deterministic is the number of walks that take the same choice of
+	// neighbor every time: the nearest, the next nearest, and so on
+	Deterministic int
+	// Randomized is the number of Monte Carlo walks, which choose a
+	// neighbor at random every time
+	Randomized int
+}
+
+// DefaultWalkParams returns models.MaxNearestNeighbors of each kind of start,
+// choosing between models.MaxNearestNeighbors neighbors
+func DefaultWalkParams() WalkParams {
+	return WalkParams{
+		Neighbors:     models.MaxNearestNeighbors,
+		Deterministic: models.MaxNearestNeighbors,
+		Randomized:    models.MaxNearestNeighbors,
+	}
+}
+
+// Validate checks that the walks have neighbors to choose from and that
+// there is at least one of them
+func (p WalkParams) Validate() error {
+	if p.Neighbors < 1 {
+		return fmt.Errorf("a walk needs at least one neighbor to choose from, got %d", p.Neighbors)
+	}
+	if p.Deterministic < 0 || p.Randomized < 0 || p.Deterministic+p.Randomized == 0 {
+		return fmt.Errorf("there must be at least one walk, got %d deterministic and %d randomized",
+			p.Deterministic, p.Randomized)
+	}
+	return nil
+}
+
+// NearestNeighbor builds routes by repeatedly driving to one of the nearest
+// pickups that can still be completed within the shift.  The registered
+// strategy takes its walks from the Params it is looked up with.
+type NearestNeighbor struct {
+	Walk WalkParams
+}
+
+// Name identifies the strategy in the registry
+func (*NearestNeighbor) Name() string {
+	return "nearest"
+}
+
+// Starts returns the number of each kind of walk.  The randomized starts are
+// effectively a set of Monte Carlo experiments, varying the choice of next load
+// (within a set of size Walk.Neighbors) randomly, while the deterministic starts
+// choose the same neighbor every time (i.e. start 0 chooses the nearest neighbor
+// every time, start 1 chooses the next nearest neighbor every time, etc.)  Note
+// that nearest neighbor in this sense is defined as the non-completed load who's
+// pickup point is closest to the current dropoff point.
+func (n *NearestNeighbor) Starts() (int, int) {
+	return n.Walk.Deterministic, n.Walk.Randomized
+}
+
+// Construct walks from load to load, choosing between the nearest pickups
+// as the start directs
+func (n *NearestNeighbor) Construct(loadset *models.LoadSet, start int, rng *rand.Rand) *models.DriverStable {
+	// Clone the loadset so that nodes are initially marked as not completed
+	ls := loadset.Clone()
+
+	// Create a stable of drivers
+	stable := models.NewDriverStable(ls, rng)
+	// Get a new driver to start the solution
+	driver := stable.DispatchNewDriver()
+	// While there are loads that have not been completed, continue the algorithm
+	for !ls.IsFinished() {
+		// Find out if the driver can complete another load
+		finished := driver.FindNearestPickup(start, n.Walk.Neighbors)
+		// If the driver was unable to pickup another load, send it
+		// home and get a new driver
+		if finished {
+			driver.ReturnHome()
+			driver = stable.DispatchNewDriver()
+			continue
+		}
+	}
+
+	// When all loads have been completed, the last driver is at the dropoff
+	// location of the final load, and needs to get back home
+	driver.ReturnHome()
+
+	return stable
+}
+
+// Improve moves loads within and between the routes of the drivers
+func (*NearestNeighbor) Improve(ctx context.Context, stable *models.DriverStable) {
+	stable.Improve(ctx)
+}
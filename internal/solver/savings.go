@@ -0,0 +1,37 @@
+package solver
+
+import (
+	"context"
+	"math/rand"
+	"sched/internal/models"
+)
+
+func init() {
+	Register("savings", func(Params) (Strategy, error) {
+		return savings{}, nil
+	})
+}
+
+// savings builds routes by merging single load routes in order
+// of the distance saved (Clarke-Wright)
+type savings struct{}
+
+func (savings) Name() string {
+	return "savings"
+}
+
+// Starts returns a single deterministic start, as there is only one savings solution
+func (savings) Starts() (int, int) {
+	return 1, 0
+}
+
+func (savings) Construct(loadset *models.LoadSet, _ int, rng *rand.Rand) *models.DriverStable {
+	stable := models.NewDriverStable(loadset.Clone(), rng)
+	stable.BuildSavings()
+	return stable
+}
+
+// Improve moves loads within and between the routes of the drivers
+func (savings) Improve(ctx context.Context, stable *models.DriverStable) {
+	stable.Improve(ctx)
+}
@@ -1,16 +1,44 @@
 // Package solver contains an algorithm framework
 // for finding a good solution to a loading problem.
-// Note that the majority of work in this algorithm is
-// actually performed in the FindNearestPickup method of
-// the driver struct
+// Each approach to the problem is a registered Strategy;
+// note that the majority of work in the default nearest
+// neighbor strategy is actually performed in the
+// FindNearestPickup method of the driver struct
 package solver
 
 import (
+	"context"
 	"fmt"
-	"math"
+	"math/rand"
+	"runtime"
 	"sched/internal/models"
+	"sync"
+	"sync/atomic"
 )
 
+// Options controls how SolveLoadSet searches for a solution
+type Options struct {
+	// Strategies are run in turn, and the cheapest solution of any of them is kept
+	Strategies []Strategy
+	// Workers is the number of starts that run at the same time.
+	// Zero or less uses one worker per available processor.
+	Workers int
+	// Seed determines all of the random choices made while solving
+	Seed int64
+	// Debug turns on debug printing
+	Debug bool
+}
+
+// Result is the outcome of solving a load set
+type Result struct {
+	// Stable holds the drivers of the cheapest solution found, or nil if none was
+	Stable *models.DriverStable
+	// Strategy is the name of the strategy that found the solution
+	Strategy string
+	// Seed is the seed that all of the random choices were derived from
+	Seed int64
+}
+
 // SolveLoadSet is called to produce a solution to the loading
 // problem and to print out the solution in the form
 //
@@ -18,66 +46,222 @@ import (
 // 2,3
 // 4
 //
-// where each line represents the route of an individual driver
-func SolveLoadSet(loadset *models.LoadSet, debug bool) []string {
-	var minCost uint64 = math.MaxUint64
-	var bestStable *models.DriverStable
+// where each line represents the route of an individual driver.
+// The solution is found as described for Solve.
+func SolveLoadSet(ctx context.Context, loadset *models.LoadSet, opts Options) []string {
+	result := Solve(ctx, loadset, opts)
+	if result.Stable == nil {
+		return []string{}
+	}
+	return result.Stable.Solution()
+}
 
-	// We're going to try 2 * models.MaxNearestNeighbors paths through the system.
-	// The negative values will effectively be a set of Monte Carlo experiments, varying the
-	// choice of next load (within a set of size models.MaxNearestNeighbors) randomly,
-	// while the positive values will choose that neighbor (i.e. i = 0 chooses the nearest neighbor
-	// every time, i=1 chooses the next nearest neighbor every time, etc.) Note that nearest
-	// neighbor in this sense is defined as the non-completed load who's pickup point is closest
-	// to the current dropoff point.
-	for i := -models.MaxNearestNeighbors; i < models.MaxNearestNeighbors; i++ {
-		// Clone the loadset so that nodes are initially marked as not completed
-		ls := loadset.Clone()
-
-		// Create a stable of drivers
-		stable := models.NewDriverStable(ls)
-		// Get a new driver to start the solution
-		driver := stable.DispatchNewDriver()
-		// While there are loads that have not been completed, continue the algorithm
-		for !ls.IsFinished() {
-			// Find out if the driver can complete another load
-			finished := driver.FindNearestPickup(i)
-			// If the driver was unable to pickup another load, send it
-			// home and get a new driver
-			if finished {
-				driver.ReturnHome()
-				driver = stable.DispatchNewDriver()
-				continue
-			}
+// Solve finds a solution to the loading problem.
+//
+// Each strategy runs through its starts, and the cheapest of the solutions
+// it constructs is improved.  If ctx carries a deadline, strategies with
+// randomized starts then keep constructing and improving new solutions until
+// the deadline passes.  The cheapest solution found overall is returned.
+//
+// Starts are spread over a pool of workers.  Every start has a random source
+// of its own, derived from the seed, and ties in cost go to the earliest start,
+// so the number of workers does not change which solution wins.  Without a
+// deadline on ctx (and without time budgets on the strategies themselves),
+// the same seed and load set always produce the same solution.
+func Solve(ctx context.Context, loadset *models.LoadSet, opts Options) *Result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	seed := opts.Seed
+	if opts.Debug {
+		_, _ = fmt.Printf("Random seed: %d\n", seed)
+	}
+
+	// Loads that do not fit in any shift would keep the strategies dispatching drivers forever
+	if len(loadset.Unservable()) > 0 {
+		println("No solution could be found: some loads cannot be completed within a shift")
+		return &Result{Seed: seed}
+	}
+
+	// Construct every start of every strategy, keeping the results in
+	// start order so that the choice of the cheapest is deterministic
+	type start struct {
+		strategy int
+		start    int
+	}
+	starts := []start{}
+	for i, strategy := range opts.Strategies {
+		deterministic, randomized := strategy.Starts()
+		for s := -randomized; s < deterministic; s++ {
+			starts = append(starts, start{i, s})
 		}
+	}
+	constructed := make([]*models.DriverStable, len(starts))
+	parallel(workers, len(starts), func(i int) {
+		st := starts[i]
+		rng := startRand(seed, st.strategy, st.start)
+		constructed[i] = opts.Strategies[st.strategy].Construct(loadset, st.start, rng)
+	})
 
-		// When all loads have been completed, the last driver is at the dropoff
-		// location of the final load, and needs to get back home
-		driver.ReturnHome()
+	// Improve the cheapest construction of each strategy
+	candidates := make([]*models.DriverStable, len(opts.Strategies))
+	for i, stable := range constructed {
+		candidates[starts[i].strategy] = cheaper(candidates[starts[i].strategy], stable)
+	}
+	constructedCosts := make([]float64, len(candidates))
+	for i, stable := range candidates {
+		if stable != nil {
+			constructedCosts[i] = stable.CalculateCost()
+		}
+	}
+	parallel(workers, len(candidates), func(i int) {
+		if candidates[i] != nil {
+			opts.Strategies[i].Improve(ctx, candidates[i])
+		}
+	})
 
-		// Calculate the cost for this solution
-		cost := stable.CalculateCost()
+	var bestStable *models.DriverStable
+	bestStrategy := -1
+	for i, stable := range candidates {
+		if stable == nil {
+			continue
+		}
+		if opts.Debug {
+			_, _ = fmt.Printf("Strategy %s: cost %.2f after construction, %.2f after improvement\n",
+				opts.Strategies[i].Name(), constructedCosts[i], stable.CalculateCost())
+		}
+		if next := cheaper(bestStable, stable); next != bestStable {
+			bestStable, bestStrategy = next, i
+		}
+	}
 
-		// Keep track of the minimum cost solution
-		if cost < minCost {
-			minCost = cost
-			bestStable = stable
+	// Use whatever time is left on randomized starts
+	if _, ok := ctx.Deadline(); ok {
+		restarted, strategy, restarts := restart(ctx, loadset, opts.Strategies, workers, seed)
+		if next := cheaper(bestStable, restarted); next != bestStable {
+			bestStable, bestStrategy = next, strategy
+		}
+		if opts.Debug {
+			_, _ = fmt.Printf("Randomized restarts within the time budget: %d\n", restarts)
 		}
 	}
 
 	// If no solution was found, say so.
 	if bestStable == nil {
 		println("No solution could be found")
-		return []string{}
+		return &Result{Seed: seed}
 	}
 
-	if debug {
+	if opts.Debug {
 		size, uniqueSize := bestStable.Size()
 		_, _ = fmt.Printf("Size of solution set: %d\n", size)
 		_, _ = fmt.Printf("Number of unique solutions in solution set: %d\n", uniqueSize)
+		_, _ = fmt.Printf("Cost of solution: %.2f\n", bestStable.CalculateCost())
 		println()
 	}
 
-	// Print the loads for each driver
-	return bestStable.Solution()
+	return &Result{
+		Stable:   bestStable,
+		Strategy: opts.Strategies[bestStrategy].Name(),
+		Seed:     seed,
+	}
+}
+
+// restart keeps constructing and improving solutions from the randomized starts
+// of the strategies, taking turns between them, until ctx is done.  Each strategy
+// continues the count of its starts, so that every start is a new one.  It returns
+// the cheapest solution found, preferring earlier restarts on a tie, along with the
+// index of the strategy that found it and the number of restarts that were completed.
+func restart(ctx context.Context, loadset *models.LoadSet, strategies []Strategy, workers int, seed int64) (*models.DriverStable, int, int) {
+	randomized := []int{}
+	for i, strategy := range strategies {
+		if _, r := strategy.Starts(); r > 0 {
+			randomized = append(randomized, i)
+		}
+	}
+	// Without any randomized strategies, there is nothing left to try
+	if len(randomized) == 0 {
+		return nil, -1, 0
+	}
+
+	var mu sync.Mutex
+	var bestStable *models.DriverStable
+	bestRestart := 0
+	bestStrategy := -1
+	restarts := 0
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				n := int(atomic.AddInt64(&next, 1))
+				i := randomized[n%len(randomized)]
+				_, r := strategies[i].Starts()
+				start := -r - 1 - n/len(randomized)
+
+				stable := strategies[i].Construct(loadset, start, startRand(seed, i, start))
+				if stable == nil {
+					continue
+				}
+				strategies[i].Improve(ctx, stable)
+
+				mu.Lock()
+				restarts++
+				if bestStable == nil || stable.CalculateCost() < bestStable.CalculateCost() ||
+					(stable.CalculateCost() == bestStable.CalculateCost() && n < bestRestart) {
+					bestStable = stable
+					bestRestart = n
+					bestStrategy = i
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return bestStable, bestStrategy, restarts
+}
+
+// cheaper returns whichever of the solutions costs less, preferring
+// the first on a tie.  Either of them may be nil.
+func cheaper(first, second *models.DriverStable) *models.DriverStable {
+	if second == nil {
+		return first
+	}
+	if first == nil || second.CalculateCost() < first.CalculateCost() {
+		return second
+	}
+	return first
+}
+
+// parallel calls work for each number from 0 up to n, spread over the given
+// number of goroutines, and returns once all of the calls have finished
+func parallel(workers, n int, work func(i int)) {
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				work(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// startRand creates the random source for a single start of a strategy.
+// The source depends only on the seed, the strategy and the start, and
+// not on which worker happens to run the start.
+func startRand(seed int64, strategy int, start int) *rand.Rand {
+	h := uint64(seed)
+	for _, v := range []int{strategy, start} {
+		h ^= uint64(v) + 0x9e3779b97f4a7c15 + h<<6 + h>>2
+	}
+	return rand.New(rand.NewSource(int64(h)))
 }
@@ -1,25 +1,74 @@
 package solver
 
 import (
+	"context"
+	"math"
+	"sched/internal/models"
 	"sched/internal/reader"
+	"sched/internal/validator"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSolution(t *testing.T) {
-	loadset := reader.CreateLoadSet("./testfiles/problem.txt")
-	if loadset == nil {
-		t.Fatal("could not read problem file")
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	solution := SolveLoadSet(loadset, false)
+	solution := SolveLoadSet(context.Background(), loadset, Options{Strategies: lookup(t, "nearest"), Seed: 1})
 
 	actualSolution := []string{
-		"88,36,13,176,101,183,147,71,15,28,173,163,168,38,153,86,130,17,184,121,115,1,44,62,125,18,145,104,2,19,133,165,185,177,200,45,193,81,198,92,102,85,152,14,109",
-		"117,129,100,106,136,154,66,21,151,181,174,144,47,75,105,195,156,192,166,124,79,134,196,122,98,108,77,178,53,60,12,119,116,78,67,49,83",
-		"160,91,73,68,48,146,143,167,161,35,137,23,175,52,84,59,141,194,89,126,61,82,159,103,58,170,54,158,74,30,37,69,7,164",
-		"6,179,190,72,162,197,46,11,138,41,65,191,87,33,27,107,140,169,149,26,114,95,40,150,97,155,34,31,172,157,70,96,93",
-		"120,112,135,180,139,16,187,182,110,113,22,5,20,142,50,32,25,171,51,132,94,128,90,3,39,56,148,123,189,199,76",
-		"131,186,99,111,57,63,80,42,43,9,10,24,4,188,118,127,55,64,29,8",
+		"88,36,13,176,96",
+		"117,129,100",
+		"123,81,198,162,197",
+		"6,179,190,154,66",
+		"120,108,77,178",
+		"22,7,52,59",
+		"138,155,40,156",
+		"8,50,150,41,168",
+		"107,193,38,33,103",
+		"74,26,65,15,28,173",
+		"182,134,152,158",
+		"64,14,95,196",
+		"194,89,149,69",
+		"135,164,146",
+		"24,48,183,147",
+		"93,98,122,177",
+		"128,23,167",
+		"4,47,75",
+		"10,3,139,105,44",
+		"57,137,84,175,101",
+		"186,157,70",
+		"46,16,20,67,53",
+		"199,143,115,11",
+		"63,161,12,58",
+		"31,114,73",
+		"76,79,166,85",
+		"83,60,62,110,113",
+		"29,97,124",
+		"131,174,151,200,45",
+		"90,121,119,171",
+		"99,126,102",
+		"25,9,68,141",
+		"188,1,32",
+		"21,19,195",
+		"80,159,82,17,184",
+		"127,153,86,130",
+		"132,191,37,61,27",
+		"43,5,72,136",
+		"170,54,106",
+		"51,39,116,2",
+		"56,165,185",
+		"189,172,30,169,111",
+		"145,104,192",
+		"140,42,142,148",
+		"94,71,180,35,163",
+		"55,49,109,34",
+		"187,78,112,125,18",
+		"118,181,144,133",
+		"160,87,91,92",
 	}
 
 	if len(solution) != len(actualSolution) {
@@ -32,3 +81,272 @@ func TestSolution(t *testing.T) {
 		}
 	}
 }
+
+func TestSavingsSolution(t *testing.T) {
+	testCompleteSolution(t, context.Background(), lookup(t, "savings"))
+}
+
+func TestAnnealingSolution(t *testing.T) {
+	testCompleteSolution(t, context.Background(), lookup(t, "anneal"))
+}
+
+func TestAdaptiveSearchSolution(t *testing.T) {
+	params := models.DefaultALNSParams()
+	params.Iterations = 200
+	testCompleteSolution(t, context.Background(), []Strategy{&AdaptiveSearch{Walk: DefaultWalkParams(), Params: params}})
+}
+
+func TestWalkParams(t *testing.T) {
+	// A single greedy walk, and a wider search than the default
+	testCompleteSolution(t, context.Background(), []Strategy{&NearestNeighbor{Walk: WalkParams{Neighbors: 1, Deterministic: 1}}})
+	testCompleteSolution(t, context.Background(), []Strategy{&NearestNeighbor{Walk: WalkParams{Neighbors: 20, Deterministic: 20, Randomized: 20}}})
+
+	if err := (WalkParams{Neighbors: 0, Deterministic: 1}).Validate(); err == nil {
+		t.Fatal("expected an error for walks without neighbors")
+	}
+	if err := (WalkParams{Neighbors: 5}).Validate(); err == nil {
+		t.Fatal("expected an error for no walks")
+	}
+}
+
+func TestWindows(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/windows.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := models.DefaultALNSParams()
+	params.Iterations = 200
+	strategies := append(lookup(t, "nearest", "savings", "anneal"), &AdaptiveSearch{Walk: DefaultWalkParams(), Params: params})
+	for _, strategy := range strategies {
+		solution := SolveLoadSet(context.Background(), loadset, Options{Strategies: []Strategy{strategy}, Seed: 1})
+		routes := make([][]string, len(solution))
+		for i, route := range solution {
+			routes[i] = strings.Split(route, ",")
+		}
+		if report := validator.Validate(loadset, routes); !report.Valid() {
+			t.Fatalf("strategy %s broke the windows or the shift limit: %+v", strategy.Name(), report)
+		}
+	}
+}
+
+func TestServiceTimes(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/windows.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadset.SetDefaultService(models.Service{Loading: 10, Unloading: 5})
+
+	for _, strategy := range lookup(t, "nearest", "savings") {
+		result := Solve(context.Background(), loadset, Options{Strategies: []Strategy{strategy}, Seed: 1})
+		if result.Stable == nil {
+			t.Fatalf("strategy %s found no solution", strategy.Name())
+		}
+		routes := [][]string{}
+		for _, summary := range result.Stable.Summaries() {
+			if math.Abs(summary.ServiceMinutes-15*float64(len(summary.Loads))) > 1e-9 {
+				t.Fatalf("strategy %s left out time at the docks, got %v minutes for %d loads",
+					strategy.Name(), summary.ServiceMinutes, len(summary.Loads))
+			}
+			routes = append(routes, summary.Loads)
+		}
+		if report := validator.Validate(loadset, routes); !report.Valid() {
+			t.Fatalf("strategy %s broke the windows or the shift limit: %+v", strategy.Name(), report)
+		}
+	}
+}
+
+func TestSameSeed(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Randomized improvement, with the work spread differently each time
+	params := models.DefaultALNSParams()
+	params.Iterations = 100
+	opts := Options{Strategies: []Strategy{&AdaptiveSearch{Walk: DefaultWalkParams(), Params: params}}, Seed: 42, Workers: 1}
+	first := SolveLoadSet(context.Background(), loadset, opts)
+	opts.Workers = 4
+	second := SolveLoadSet(context.Background(), loadset, opts)
+
+	if strings.Join(first, "|") != strings.Join(second, "|") {
+		t.Fatalf("different solutions for the same seed.  first='%v', second='%v'", first, second)
+	}
+}
+
+func TestResult(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := Solve(context.Background(), loadset, Options{Strategies: lookup(t, "nearest", "savings"), Seed: 7})
+	if result.Stable == nil || result.Seed != 7 {
+		t.Fatalf("should have found a solution with seed 7, got %+v", result)
+	}
+	if result.Strategy != "nearest" && result.Strategy != "savings" {
+		t.Fatalf("unexpected strategy '%s'", result.Strategy)
+	}
+
+	// The summaries of the routes add up to the cost of the solution
+	var minutes float64
+	itineraries := result.Stable.Itineraries(480)
+	for i, summary := range result.Stable.Summaries() {
+		// The itinerary runs without a break from the start to the end of the shift
+		legs := itineraries[i]
+		if len(legs) != 2*len(summary.Loads)+1 || legs[0].Start != 480 ||
+			math.Abs(legs[len(legs)-1].End-480-summary.Minutes) > 1e-6 {
+			t.Fatalf("itinerary %+v does not match route summary %+v", legs, summary)
+		}
+		for j := 1; j < len(legs); j++ {
+			if legs[j].Start != legs[j-1].End {
+				t.Fatalf("gap in itinerary %+v", legs)
+			}
+		}
+		if math.Abs(summary.LoadedMinutes+summary.DeadheadMinutes-summary.Minutes) > 1e-6 ||
+			math.Abs(summary.Minutes+summary.SlackMinutes-result.Stable.ShiftLimit()) > 1e-6 {
+			t.Fatalf("inconsistent route summary %+v", summary)
+		}
+		minutes += summary.Minutes
+	}
+	driverCost, distanceCost := result.Stable.CostBreakdown()
+	if math.Abs(distanceCost-minutes) > 1e-6 || math.Abs(driverCost+distanceCost-result.Stable.CalculateCost()) > 1e-6 {
+		t.Fatalf("cost breakdown %.2f + %.2f does not match the routes", driverCost, distanceCost)
+	}
+}
+
+func TestCostModel(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadset.SetCostModel(models.CostModel{PerDriver: 800, PerMinute: 2})
+	loadset.SetConstraints(models.Constraints{MaxShiftMinutes: 660})
+
+	result := Solve(context.Background(), loadset, Options{Strategies: lookup(t, "nearest"), Seed: 1})
+	if result.Stable == nil {
+		t.Fatal("should have found a solution")
+	}
+	var minutes float64
+	for _, summary := range result.Stable.Summaries() {
+		if summary.Minutes > 660 {
+			t.Fatalf("route %v takes %.2f minutes, over the limit of 660", summary.Loads, summary.Minutes)
+		}
+		minutes += summary.Minutes
+	}
+	drivers := len(result.Stable.Solution())
+	if math.Abs(result.Stable.CalculateCost()-800*float64(drivers)-2*minutes) > 1e-6 {
+		t.Fatalf("cost %.2f does not follow the cost model", result.Stable.CalculateCost())
+	}
+
+	// Some loads cannot be completed in a shift of 10 hours
+	loadset.SetConstraints(models.Constraints{MaxShiftMinutes: 600})
+	if result := Solve(context.Background(), loadset, Options{Strategies: lookup(t, "nearest"), Seed: 1}); result.Stable != nil {
+		t.Fatal("should not have found a solution")
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	budget := 2 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	start := time.Now()
+	testCompleteSolution(t, ctx, lookup(t, "nearest"))
+	if elapsed := time.Since(start); elapsed > budget+time.Second {
+		t.Fatalf("solving took %s with a budget of %s", elapsed, budget)
+	}
+}
+
+// testCompleteSolution checks that solving with the
+// strategies delivers every load exactly once
+func testCompleteSolution(t *testing.T, ctx context.Context, strategies []Strategy) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solution := SolveLoadSet(ctx, loadset, Options{Strategies: strategies})
+	if len(solution) == 0 {
+		t.Fatal("no solution found")
+	}
+
+	delivered := make(map[string]int)
+	for _, route := range solution {
+		for _, load := range strings.Split(route, ",") {
+			delivered[load]++
+		}
+	}
+
+	if len(delivered) != loadset.Size() {
+		t.Fatalf("wrong number of loads delivered.  wanted=%d, got=%d", loadset.Size(), len(delivered))
+	}
+	for load, count := range delivered {
+		if count != 1 {
+			t.Fatalf("load %s delivered %d times", load, count)
+		}
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	if _, err := LookupAll([]string{"nearest", "unknown"}, DefaultParams()); err == nil {
+		t.Fatal("should not have found an unregistered strategy")
+	}
+}
+
+func TestLookupParams(t *testing.T) {
+	p := DefaultParams()
+	p.Walk = WalkParams{Neighbors: 3, Deterministic: 2, Randomized: 1}
+	p.Anneal.Iterations = 50
+	p.ALNS.Iterations = 60
+
+	// Every strategy that walks takes the walks it is looked up with
+	strategies, err := LookupAll([]string{"nearest", "anneal", "alns"}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, strategy := range strategies {
+		if deterministic, randomized := strategy.Starts(); deterministic != 2 || randomized != 1 {
+			t.Fatalf("wrong starts for %s.  wanted=2 and 1, got=%d and %d", strategy.Name(), deterministic, randomized)
+		}
+	}
+	if got := strategies[1].(*Annealing).Schedule.Iterations; got != 50 {
+		t.Fatalf("wrong anneal iterations.  wanted=50, got=%d", got)
+	}
+	if got := strategies[2].(*AdaptiveSearch).Params.Iterations; got != 60 {
+		t.Fatalf("wrong alns iterations.  wanted=60, got=%d", got)
+	}
+
+	// Strategies looked up with other settings are built afresh
+	walk := DefaultWalkParams()
+	for _, strategy := range lookup(t, "nearest", "anneal", "alns") {
+		if deterministic, randomized := strategy.Starts(); deterministic != walk.Deterministic || randomized != walk.Randomized {
+			t.Fatalf("%s should have the default walks, got %d and %d", strategy.Name(), deterministic, randomized)
+		}
+	}
+
+	// Settings that do not suit a strategy are errors
+	for _, tt := range []struct {
+		name  string
+		setup func(p *Params)
+	}{
+		{"nearest", func(p *Params) { p.Walk.Neighbors = 0 }},
+		{"anneal", func(p *Params) { p.Anneal.Iterations, p.Anneal.Budget = 0, 0 }},
+		{"alns", func(p *Params) { p.ALNS.Iterations, p.ALNS.Budget = 0, 0 }},
+	} {
+		p := DefaultParams()
+		tt.setup(&p)
+		if _, err := Lookup(tt.name, p); err == nil {
+			t.Fatalf("expected an error building %s", tt.name)
+		}
+	}
+}
+
+func lookup(t *testing.T, names ...string) []Strategy {
+	strategies, err := LookupAll(names, DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strategies
+}
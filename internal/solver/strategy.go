@@ -0,0 +1,101 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sched/internal/models"
+	"sort"
+	"strings"
+)
+
+// Strategy is a way of solving a load set, split into the construction of a
+// complete solution and the improvement of that solution afterwards.  New
+// heuristics are made available to SolveLoadSet by registering a Factory that
+// builds the Strategy.
+type Strategy interface {
+	// Name identifies the strategy in the registry
+	Name() string
+	// Starts returns how many deterministic and how many randomized
+	// constructions make up a single run of the strategy
+	Starts() (deterministic int, randomized int)
+	// Construct builds a complete solution to the load set.  Starts from 0
+	// up to the number of deterministic starts always build the same solution,
+	// while negative starts build randomized (Monte Carlo) solutions.  Starts
+	// run concurrently, so implementations should work on a clone of the load
+	// set, leaving the original untouched, and take all of their randomness
+	// from rng, which also goes to the stable for use in Improve.
+	Construct(loadset *models.LoadSet, start int, rng *rand.Rand) *models.DriverStable
+	// Improve lowers the cost of a solution built by Construct where it can,
+	// stopping early when ctx is done
+	Improve(ctx context.Context, stable *models.DriverStable)
+}
+
+// Params holds the settings that registered strategies are built with.  Each
+// strategy takes the settings that apply to it and ignores the rest.
+type Params struct {
+	// Walk sets up the nearest neighbor walks that build starting solutions
+	Walk WalkParams
+	// Anneal is the schedule of the anneal strategy
+	Anneal models.AnnealSchedule
+	// ALNS sets up the search of the alns strategy
+	ALNS models.ALNSParams
+}
+
+// DefaultParams returns the default settings of every strategy
+func DefaultParams() Params {
+	return Params{
+		Walk:   DefaultWalkParams(),
+		Anneal: models.DefaultAnnealSchedule(),
+		ALNS:   models.DefaultALNSParams(),
+	}
+}
+
+// Factory builds a strategy with the given settings, or returns an error if
+// the settings do not suit it
+type Factory func(p Params) (Strategy, error)
+
+var registry = make(map[string]Factory)
+
+// Register makes a strategy available by name, built by the factory each time
+// it is looked up.  It panics if a strategy with the same name has already been
+// registered.
+func Register(name string, factory Factory) {
+	if _, ok := registry[name]; ok {
+		panic("solver: strategy registered twice: " + name)
+	}
+	registry[name] = factory
+}
+
+// Lookup builds the registered strategy with the given name
+func Lookup(name string, p Params) (Strategy, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy '%s', expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return factory(p)
+}
+
+// LookupAll builds the registered strategies with the given names, in order,
+// or returns an error for the first one that cannot be built
+func LookupAll(names []string, p Params) ([]Strategy, error) {
+	strategies := make([]Strategy, len(names))
+	for i, name := range names {
+		s, err := Lookup(name, p)
+		if err != nil {
+			return nil, err
+		}
+		strategies[i] = s
+	}
+	return strategies, nil
+}
+
+// Names returns the names of all registered strategies in alphabetical order
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,161 @@
+// Package validator checks a solution against the problem it claims to solve,
+// independently of how the solution was produced, and scores it
+package validator
+
+import (
+	"fmt"
+	"math"
+	"sched/internal/models"
+)
+
+// home is where every shift starts and ends
+var home = models.NewLocation(models.Home, 0, 0)
+
+// Route is the outcome of checking a single driver's route
+type Route struct {
+	// Loads are the IDs of the loads of the route, in order
+	Loads []string
+	// Minutes is the length of the shift, including any waiting for windows to
+	// open, counting only the loads that exist
+	Minutes float64
+	// Problems describes everything that is wrong with the route
+	Problems []string
+}
+
+// Report is the outcome of checking a whole solution
+type Report struct {
+	Routes []Route
+	// Missing lists the loads of the problem that no driver delivers,
+	// in the order they appear in the problem
+	Missing []string
+	// TotalMinutes is the sum of the lengths of all of the shifts
+	TotalMinutes float64
+	// Cost is the cost of the solution, as calculated by the scheduler
+	Cost float64
+}
+
+// Valid reports whether the solution delivers every load exactly once,
+// only delivers loads that are in the problem, and keeps every driver
+// within the shift limit and the windows of the loads
+func (r *Report) Valid() bool {
+	if len(r.Missing) > 0 {
+		return false
+	}
+	for _, route := range r.Routes {
+		if len(route.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks each route of a solution, given as the load IDs
+// delivered by each driver, against the load set of the problem
+func Validate(loadset *models.LoadSet, routes [][]string) *Report {
+	report := &Report{Routes: make([]Route, len(routes))}
+
+	// The first driver (counting from 1) to deliver each load
+	deliveredBy := make(map[string]int)
+	drivers := 0
+
+	for i, ids := range routes {
+		route := Route{Loads: ids, Problems: []string{}}
+
+		loads := []*models.Load{}
+		for _, id := range ids {
+			load, ok := loadset.Load(id)
+			if !ok {
+				route.Problems = append(route.Problems, fmt.Sprintf("load %s is not in the problem", id))
+				continue
+			}
+			if first, ok := deliveredBy[id]; ok {
+				if first == i+1 {
+					route.Problems = append(route.Problems, fmt.Sprintf("load %s is delivered more than once", id))
+				} else {
+					route.Problems = append(route.Problems, fmt.Sprintf("load %s is also delivered by driver %d", id, first))
+				}
+				continue
+			}
+			deliveredBy[id] = i + 1
+			loads = append(loads, load)
+		}
+
+		if len(ids) == 0 {
+			route.Problems = append(route.Problems, "the route has no loads")
+		} else {
+			drivers++
+		}
+
+		var missed []string
+		route.Minutes, missed = shift(loadset, loads)
+		route.Problems = append(route.Problems, missed...)
+		if route.Minutes > loadset.ShiftLimit() {
+			route.Problems = append(route.Problems,
+				fmt.Sprintf("the shift of %.2f minutes exceeds the limit of %.0f minutes", route.Minutes, loadset.ShiftLimit()))
+		}
+
+		report.TotalMinutes += route.Minutes
+		report.Routes[i] = route
+	}
+
+	report.Missing = []string{}
+	for n := 1; n < len(loadset.LoadMap); n++ {
+		id := loadset.LoadMap[n].ID
+		if _, ok := deliveredBy[id]; !ok {
+			report.Missing = append(report.Missing, id)
+		}
+	}
+
+	report.Cost = loadset.CostModel().Cost(drivers, report.TotalMinutes)
+	return report
+}
+
+// shift times a driver who completes the loads in order, measuring every leg
+// from the locations of the loads rather than trusting the distance matrix of
+// the load set.  The driver waits for windows that have not opened yet and
+// carries on past windows that are missed, so that the length of the shift
+// can still be reported along with the windows that are missed.
+func shift(loadset *models.LoadSet, loads []*models.Load) (float64, []string) {
+	service := loadset.DefaultService()
+	missed := []string{}
+	var clock float64
+	at := home
+	for _, load := range loads {
+		clock += distance(loadset, at, load.Pickup)
+		if load.PickupWindow.Missed(clock) {
+			missed = append(missed, fmt.Sprintf("load %s is picked up at minute %.2f, after its window closes at minute %g",
+				load.ID, clock, load.PickupWindow.Latest))
+		}
+		clock = math.Max(clock, load.PickupWindow.Earliest) + minutes(load.Loading, service.Loading)
+
+		clock += distance(loadset, load.Pickup, load.Dropoff)
+		if load.DropoffWindow.Missed(clock) {
+			missed = append(missed, fmt.Sprintf("load %s is dropped off at minute %.2f, after its window closes at minute %g",
+				load.ID, clock, load.DropoffWindow.Latest))
+		}
+		clock = math.Max(clock, load.DropoffWindow.Earliest) + minutes(load.Unloading, service.Unloading)
+
+		at = load.Dropoff
+	}
+	if len(loads) > 0 {
+		clock += distance(loadset, at, home)
+	}
+	return clock, missed
+}
+
+// distance is the number of minutes it takes to drive in a straight line
+// between the locations, using the coordinates the load set measures with
+func distance(loadset *models.LoadSet, from, to *models.Location) float64 {
+	if loadset.Rounded() {
+		return math.Hypot(float64(to.X-from.X), float64(to.Y-from.Y))
+	}
+	return math.Hypot(to.ExactX-from.ExactX, to.ExactY-from.ExactY)
+}
+
+// minutes returns the service time of a load, or the default if it has none
+func minutes(own *float64, fallback float64) float64 {
+	if own != nil {
+		return *own
+	}
+	return fallback
+}
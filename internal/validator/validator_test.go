@@ -0,0 +1,166 @@
+package validator
+
+import (
+	"math"
+	"sched/internal/models"
+	"sched/internal/reader"
+	"testing"
+)
+
+func TestValidSolution(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Validate(loadset, [][]string{{"1", "2"}, {"3"}})
+	if !report.Valid() {
+		t.Fatalf("solution should be valid, got %+v", report)
+	}
+
+	// 80 minutes for the first driver and 660 minutes for the second
+	if math.Abs(report.TotalMinutes-740) > 1e-9 {
+		t.Fatalf("wrong total minutes, got %v", report.TotalMinutes)
+	}
+	if math.Abs(report.Cost-1740) > 1e-9 {
+		t.Fatalf("wrong cost, got %v", report.Cost)
+	}
+}
+
+func TestInvalidSolution(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Validate(loadset, [][]string{{"1", "1"}, {"1", "7"}})
+	if report.Valid() {
+		t.Fatal("solution should not be valid")
+	}
+	if len(report.Routes[0].Problems) != 1 {
+		t.Fatalf("first driver should deliver a load twice, got %v", report.Routes[0].Problems)
+	}
+	if len(report.Routes[1].Problems) != 2 {
+		t.Fatalf("second driver should repeat a load and deliver an unknown one, got %v", report.Routes[1].Problems)
+	}
+	if len(report.Missing) != 2 || report.Missing[0] != "2" || report.Missing[1] != "3" {
+		t.Fatalf("loads 2 and 3 should be missing, got %v", report.Missing)
+	}
+}
+
+func TestShiftLimit(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Load 3 takes 660 minutes on its own, leaving no time for the others
+	report := Validate(loadset, [][]string{{"3", "1", "2"}})
+	if report.Valid() || len(report.Routes[0].Problems) != 1 {
+		t.Fatalf("route should exceed the shift limit, got %v", report.Routes[0].Problems)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/windows.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Waiting 50 minutes for the window of load 1 counts towards its shift of 90 minutes
+	report := Validate(loadset, [][]string{{"2"}, {"1"}})
+	if !report.Valid() {
+		t.Fatalf("solution should be valid, got %+v", report)
+	}
+	if math.Abs(report.Routes[1].Minutes-90) > 1e-9 {
+		t.Fatalf("wrong minutes for the second driver, got %v", report.Routes[1].Minutes)
+	}
+
+	// Waiting for load 1 makes load 2 late
+	report = Validate(loadset, [][]string{{"1", "2"}})
+	if report.Valid() || len(report.Routes[0].Problems) != 1 {
+		t.Fatalf("route should miss the window of load 2, got %v", report.Routes[0].Problems)
+	}
+}
+
+func TestServiceTimes(t *testing.T) {
+	loadset, err := reader.CreateLoadSet("./testfiles/problem.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 80 minutes of driving and 15 minutes at the docks of each of two loads
+	loadset.SetDefaultService(models.Service{Loading: 10, Unloading: 5})
+	report := Validate(loadset, [][]string{{"1", "2"}})
+	if math.Abs(report.TotalMinutes-110) > 1e-9 {
+		t.Fatalf("wrong total minutes, got %v", report.TotalMinutes)
+	}
+
+	// Load 3 takes 660 minutes of driving, leaving an hour at the docks
+	loadset.SetDefaultService(models.Service{Loading: 30, Unloading: 30})
+	if report := Validate(loadset, [][]string{{"3"}}); len(report.Routes[0].Problems) != 0 {
+		t.Fatalf("route should fit in the shift, got %v", report.Routes[0].Problems)
+	}
+	loadset.SetDefaultService(models.Service{Loading: 31, Unloading: 30})
+	if report := Validate(loadset, [][]string{{"3"}}); len(report.Routes[0].Problems) != 1 {
+		t.Fatalf("route should exceed the shift limit, got %v", report.Routes[0].Problems)
+	}
+}
+
+func TestHandTimedRoute(t *testing.T) {
+	loadset := models.NewLoadSet()
+	first := models.NewLoad("A", models.NewLocation(models.Pickup, 3, 4), models.NewLocation(models.Dropoff, 3, 10), false)
+	first.PickupWindow = models.Window{Earliest: 10}
+	loading := 2.0
+	first.Loading = &loading
+	second := models.NewLoad("B", models.NewLocation(models.Pickup, 6, 14), models.NewLocation(models.Dropoff, 6, 20), false)
+	second.DropoffWindow = models.Window{Latest: 40, HasLatest: true}
+	loadset.AddLoad(first)
+	loadset.AddLoad(second)
+	loadset.FormDistanceMatrix()
+	loadset.SetDefaultService(models.Service{Loading: 4, Unloading: 3})
+
+	// A: 5 minutes to the pickup, waiting until 10, loading until 12,
+	// 6 minutes carrying and 3 unloading, done at 21.
+	// B: 5 minutes to the pickup, 4 loading, 6 carrying, dropped off at 36,
+	// 3 unloading, and sqrt(6*6+20*20) minutes home.
+	want := 39 + math.Sqrt(436)
+	report := Validate(loadset, [][]string{{"A", "B"}})
+	if !report.Valid() {
+		t.Fatalf("solution should be valid, got %+v", report)
+	}
+	if math.Abs(report.Routes[0].Minutes-want) > 1e-9 {
+		t.Fatalf("wrong minutes.  wanted=%v, got=%v", want, report.Routes[0].Minutes)
+	}
+	if math.Abs(report.Cost-(500+want)) > 1e-9 {
+		t.Fatalf("wrong cost, got %v", report.Cost)
+	}
+
+	// Dropping B off at minute 36 is now late, but the shift is just as long
+	second.DropoffWindow = models.Window{Latest: 35, HasLatest: true}
+	report = Validate(loadset, [][]string{{"A", "B"}})
+	if report.Valid() || len(report.Routes[0].Problems) != 1 {
+		t.Fatalf("route should miss the window of load B, got %v", report.Routes[0].Problems)
+	}
+	if math.Abs(report.Routes[0].Minutes-want) > 1e-9 {
+		t.Fatalf("wrong minutes.  wanted=%v, got=%v", want, report.Routes[0].Minutes)
+	}
+}
+
+func TestRoundedRoute(t *testing.T) {
+	loadset := models.NewLoadSet()
+	loadset.AddLoad(models.NewLoad("1", models.NewLocation(models.Pickup, 2.6, 0.3), models.NewLocation(models.Dropoff, 2.6, 4.3), false))
+	loadset.FormDistanceMatrix()
+
+	// 2.62 minutes to the pickup, 4 carrying and 5.02 home
+	want := math.Hypot(2.6, 0.3) + 4 + math.Hypot(2.6, 4.3)
+	if got := Validate(loadset, [][]string{{"1"}}).TotalMinutes; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("wrong exact minutes.  wanted=%v, got=%v", want, got)
+	}
+
+	// From (3,0) to (3,4): 3 minutes to the pickup, 4 carrying and 5 home
+	loadset.RoundCoordinates()
+	if got := Validate(loadset, [][]string{{"1"}}).TotalMinutes; math.Abs(got-12) > 1e-9 {
+		t.Fatalf("wrong rounded minutes.  wanted=12, got=%v", got)
+	}
+}